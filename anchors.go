@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"github.com/urfave/cli"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+const (
+	anchorsExpand   = "expand"
+	anchorsPreserve = "preserve"
+)
+
+var anchors string
+
+var anchorsFlag = cli.StringFlag{
+	Name:        "anchors",
+	Usage:       "'expand' the YAML aliases into copies of their anchored values, or 'preserve' them, which rules out the filters as --preserve-comments does; JSON has no anchors, so the other output formats are always expanded",
+	Value:       anchorsExpand,
+	Destination: &anchors,
+}
+
+// checkAnchors validates --anchors. Preserving the anchors requires
+// reformatting the yaml.v3 nodes with commentFormat.
+func checkAnchors() error {
+	if anchors != "" && anchors != anchorsExpand && anchors != anchorsPreserve {
+		return usageError(fmt.Sprintf("invalid --anchors %q, expected 'expand' or 'preserve'", anchors))
+	}
+	return nil
+}
+
+// maxAliasNodes bounds the nodes copied by expanding the aliases, which grow
+// exponentially when the anchored values alias each other, as in the billion
+// laughs document.
+const maxAliasNodes = 1000000
+
+// expandAliases replaces the aliases of the node with copies of the nodes they
+// refer to, resolves the << merge keys, and drops the anchors, like decoding
+// the YAML into objects does, but keeping the comments of the nodes.
+func expandAliases(node *yamlv3.Node) (*yamlv3.Node, error) {
+	return (&aliasExpander{expanding: map[*yamlv3.Node]bool{}}).expand(node, false)
+}
+
+// aliasExpander tracks the anchored nodes whose aliases are being expanded,
+// to reject an anchor containing itself, and the number of copied nodes.
+type aliasExpander struct {
+	expanding map[*yamlv3.Node]bool
+	copied    int
+}
+
+func (e *aliasExpander) expand(node *yamlv3.Node, aliased bool) (*yamlv3.Node, error) {
+	if node.Kind == yamlv3.AliasNode {
+		if e.expanding[node.Alias] {
+			return nil, fmt.Errorf("anchor '%s' value contains itself", node.Value)
+		}
+		return e.expand(node.Alias, true)
+	}
+	if aliased {
+		if e.copied++; e.copied > maxAliasNodes {
+			return nil, fmt.Errorf("document contains excessive aliasing, expanding it copies more than %d nodes", maxAliasNodes)
+		}
+	}
+	if node.Anchor != "" {
+		e.expanding[node] = true
+		defer delete(e.expanding, node)
+	}
+	expanded := *node
+	expanded.Anchor = ""
+	expanded.Content = nil
+	for _, child := range node.Content {
+		child, err := e.expand(child, aliased)
+		if err != nil {
+			return nil, err
+		}
+		expanded.Content = append(expanded.Content, child)
+	}
+	if expanded.Kind == yamlv3.MappingNode {
+		expanded.Content = mergeKeys(expanded.Content)
+	}
+	return &expanded, nil
+}
+
+// mergeKeys replaces the << keys of the mapping content with the pairs of the
+// mappings they merge, the keys of the mapping itself and of the earlier
+// merged mappings taking precedence.
+func mergeKeys(content []*yamlv3.Node) []*yamlv3.Node {
+	defined := map[string]bool{}
+	for i := 0; i+1 < len(content); i += 2 {
+		if !isMergeKey(content[i]) {
+			defined[content[i].Value] = true
+		}
+	}
+	var merged []*yamlv3.Node
+	for i := 0; i+1 < len(content); i += 2 {
+		key, value := content[i], content[i+1]
+		if !isMergeKey(key) {
+			merged = append(merged, key, value)
+			continue
+		}
+		sources := mergeSources(value)
+		if sources == nil {
+			// not a valid merge, keep it for the encoder to reject
+			merged = append(merged, key, value)
+			continue
+		}
+		for _, source := range sources {
+			for j := 0; j+1 < len(source.Content); j += 2 {
+				if name := source.Content[j].Value; !defined[name] {
+					defined[name] = true
+					merged = append(merged, source.Content[j], source.Content[j+1])
+				}
+			}
+		}
+	}
+	return merged
+}
+
+// mergeSources returns the mappings merged by the value of a << key,
+// a mapping or a sequence of mappings, or nil for any other value.
+func mergeSources(value *yamlv3.Node) []*yamlv3.Node {
+	sources := []*yamlv3.Node{value}
+	if value.Kind == yamlv3.SequenceNode {
+		sources = value.Content
+	}
+	for _, source := range sources {
+		if source.Kind != yamlv3.MappingNode {
+			return nil
+		}
+	}
+	return sources
+}
+
+// untagMergeKeys drops the !!merge tag of the << keys, which yaml.v3 would
+// otherwise write in front of them.
+func untagMergeKeys(node *yamlv3.Node) {
+	if node.Kind == yamlv3.MappingNode {
+		for i := 0; i < len(node.Content); i += 2 {
+			if isMergeKey(node.Content[i]) {
+				node.Content[i].Tag = ""
+			}
+		}
+	}
+	for _, child := range node.Content {
+		untagMergeKeys(child)
+	}
+}
+
+func isMergeKey(node *yamlv3.Node) bool {
+	return node.Kind == yamlv3.ScalarNode && node.Value == "<<" && (node.Tag == "!!merge" || node.Tag == "" && node.Style == 0)
+}
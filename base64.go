@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+var (
+	decodeBase64 bool
+	encodeBase64 bool
+)
+
+// base64Encodings are tried in order, so that both padded and unpadded,
+// standard and URL-safe inputs are accepted.
+var base64Encodings = []*base64.Encoding{
+	base64.StdEncoding,
+	base64.RawStdEncoding,
+	base64.URLEncoding,
+	base64.RawURLEncoding,
+}
+
+// checkBase64 rejects --decode-base64 combined with --encode-base64.
+func checkBase64() error {
+	if decodeBase64 && encodeBase64 {
+		return usageError("--decode-base64 and --encode-base64 cannot be combined")
+	}
+	return nil
+}
+
+// convertBase64 writes the base64-decoded or encoded filtered result
+// instead of its marshalled form, one line per JSONPath result.
+func convertBase64(object interface{}, marshal marshaller) ([]byte, error) {
+	values := []interface{}{object}
+	if results, ok := object.(jsonpathResults); ok {
+		values = results
+	}
+	lines := make([][]byte, len(values))
+	for i, value := range values {
+		var err error
+		if decodeBase64 {
+			lines[i], err = decodeBase64Value(value)
+		} else {
+			lines[i], err = encodeBase64Value(value, marshal)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return bytes.Join(lines, []byte("\n")), nil
+}
+
+func decodeBase64Value(value interface{}) ([]byte, error) {
+	encoded, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("cannot base64-decode %s, the selected value must be a string", jsonKind(value))
+	}
+	// wrapped content, as in PEM files, is split on several lines
+	encoded = strings.Join(strings.Fields(encoded), "")
+	var err error
+	for _, encoding := range base64Encodings {
+		var decoded []byte
+		if decoded, err = encoding.DecodeString(encoded); err == nil {
+			return decoded, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid base64 value: %v", err)
+}
+
+func encodeBase64Value(value interface{}, marshal marshaller) ([]byte, error) {
+	content, ok := value.(string)
+	if !ok {
+		marshalled, err := marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		content = string(marshalled)
+	}
+	return []byte(base64.StdEncoding.EncodeToString([]byte(content))), nil
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"github.com/urfave/cli"
+	"testing"
+)
+
+func TestDecodeBase64(t *testing.T) {
+	tests := []struct {
+		name     string
+		jsonpath string
+		input    string
+		expected string
+	}{
+		{"padded", "{.data.password}", `{"data":{"password":"c2VjcmV0IQ=="}}`, "secret!"},
+		{"unpadded", "{.data.password}", `{"data":{"password":"c2VjcmV0IQ"}}`, "secret!"},
+		{"url-safe", "{.token}", `{"token":"-_-_"}`, "\xfb\xff\xbf"},
+		{"wrapped lines", "{.cert}", `{"cert":"c2Vj\ncmV0\r\nIQ=="}`, "secret!"},
+		{"several results", "{.items[*]}", `{"items":["YQ==","Yg=="]}`, "a\nb"},
+		{"document", "", `"aGVsbG8="`, "hello"},
+	}
+	defer func(templates []string, decode bool) {
+		jsonpathTemplates, decodeBase64 = templates, decode
+	}(jsonpathTemplates, decodeBase64)
+	decodeBase64 = true
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			jsonpathTemplates = nil
+			if test.jsonpath != "" {
+				jsonpathTemplates = []string{test.jsonpath}
+			}
+			output, err := convertText(jsonFormat, jsonFormat, test.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if output != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, output)
+			}
+		})
+	}
+}
+
+func TestDecodeBase64Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		err   string
+	}{
+		{"object", `{"a":1}`, "cannot base64-decode an object, the selected value must be a string"},
+		{"number", `1`, "cannot base64-decode a number, the selected value must be a string"},
+		{"invalid base64", `"not base64!"`, "invalid base64 value: illegal base64 data at input byte 9"},
+	}
+	defer func(decode bool) { decodeBase64 = decode }(decodeBase64)
+	decodeBase64 = true
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := convertText(jsonFormat, jsonFormat, test.input)
+			if err == nil || err.Error() != test.err {
+				t.Errorf("expected the error %q, got %v", test.err, err)
+			}
+		})
+	}
+}
+
+func TestCheckBase64(t *testing.T) {
+	tests := []struct {
+		decode, encode bool
+		code           int
+	}{
+		{false, false, 0},
+		{true, false, 0},
+		{false, true, 0},
+		{true, true, exitUsage},
+	}
+	defer func(decode, encode bool) { decodeBase64, encodeBase64 = decode, encode }(decodeBase64, encodeBase64)
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("decode=%v,encode=%v", test.decode, test.encode), func(t *testing.T) {
+			decodeBase64, encodeBase64 = test.decode, test.encode
+			err := checkBase64()
+			if test.code == 0 {
+				if err != nil {
+					t.Errorf("unexpected error %v", err)
+				}
+				return
+			}
+			if exit, ok := err.(cli.ExitCoder); !ok || exit.ExitCode() != test.code {
+				t.Errorf("expected an error exiting with %d, got %v", test.code, err)
+			}
+		})
+	}
+}
+
+func TestEncodeBase64(t *testing.T) {
+	tests := []struct {
+		name     string
+		jsonpath string
+		input    string
+		expected string
+	}{
+		{"string", "{.password}", `{"password":"secret!"}`, "c2VjcmV0IQ=="},
+		{"marshalled object", "{.data}", `{"data":{"a":1}}`, "eyJhIjoxfQ=="},
+		{"several results", "{.items[*]}", `{"items":["a","b"]}`, "YQ==\nYg=="},
+	}
+	defer func(templates []string, encode bool) {
+		jsonpathTemplates, encodeBase64 = templates, encode
+	}(jsonpathTemplates, encodeBase64)
+	encodeBase64 = true
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			jsonpathTemplates = []string{test.jsonpath}
+			output, err := convertText(jsonFormat, jsonFormat, test.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if output != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, output)
+			}
+		})
+	}
+}
+
+func TestBase64RoundTrip(t *testing.T) {
+	for _, content := range []string{"", "a", "ab", "abc", "line 1\nline 2\n", "\x00\xff"} {
+		encoded, err := encodeBase64Value(content, marshalJSON)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := decodeBase64Value(string(encoded))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(decoded) != content {
+			t.Errorf("expected %q, got %q", content, decoded)
+		}
+	}
+}
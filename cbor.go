@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"github.com/fxamacker/cbor/v2"
+	"io"
+	"math/big"
+	"time"
+)
+
+var cborFormat = format{unmarshal: unmarshalCBOR}
+
+// unmarshalCBOR decodes the CBOR data items of the input, several
+// concatenated items being handled like the documents of a YAML stream.
+func unmarshalCBOR(input []byte) (interface{}, error) {
+	var objects []interface{}
+	decoder := cbor.NewDecoder(bytes.NewReader(input))
+	for {
+		var value interface{}
+		err := decoder.Decode(&value)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		object, err := cborToJSON(value)
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, object)
+	}
+
+	if len(objects) == 0 {
+		return nil, nil
+	} else if len(objects) == 1 {
+		return objects[0], nil
+	} else {
+		return objects, nil
+	}
+}
+
+// cborToJSON converts the decoded CBOR into the same structure the JSON
+// decoder produces. The byte strings become base64 strings, and the tags
+// other than the date and bignum ones are an error. The integers stay
+// integers with --preserve-int.
+func cborToJSON(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case nil, bool, string, float64:
+		return v, nil
+	case uint64:
+		if preserveInt {
+			return preservedInt(v), nil
+		}
+		return float64(v), nil
+	case int64:
+		if preserveInt {
+			return preservedInt(v), nil
+		}
+		return float64(v), nil
+	case float32:
+		return float64(v), nil
+	case *big.Int:
+		return cborBignum(v), nil
+	case big.Int:
+		return cborBignum(&v), nil
+	case []byte:
+		return base64.StdEncoding.EncodeToString(v), nil
+	case time.Time:
+		return v.Format(time.RFC3339Nano), nil
+	case map[interface{}]interface{}:
+		object := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			converted, err := cborToJSON(item)
+			if err != nil {
+				return nil, err
+			}
+			object[fmt.Sprint(key)] = converted
+		}
+		return object, nil
+	case []interface{}:
+		for i, item := range v {
+			converted, err := cborToJSON(item)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = converted
+		}
+		return v, nil
+	case cbor.Tag:
+		return nil, fmt.Errorf("unsupported CBOR tag %d", v.Number)
+	default:
+		return nil, fmt.Errorf("unsupported CBOR value of type %T", value)
+	}
+}
+
+// cborBignum converts a bignum to a float64, or to an int with --preserve-int
+// when it fits in one.
+func cborBignum(v *big.Int) interface{} {
+	if preserveInt && v.IsInt64() {
+		return preservedInt(v.Int64())
+	}
+	number, _ := new(big.Float).SetInt(v).Float64()
+	return number
+}
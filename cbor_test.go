@@ -0,0 +1,77 @@
+package main
+
+import (
+	"github.com/fxamacker/cbor/v2"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func cborEncoded(t *testing.T, values ...interface{}) []byte {
+	var input []byte
+	for _, value := range values {
+		encoded, err := cbor.Marshal(value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		input = append(input, encoded...)
+	}
+	return input
+}
+
+func TestUnmarshalCBOR(t *testing.T) {
+	bignum, _ := new(big.Int).SetString("18446744073709551616", 10)
+	tests := []struct {
+		name     string
+		input    []byte
+		expected interface{}
+	}{
+		{"empty", nil, nil},
+		{"scalars", cborEncoded(t, map[string]interface{}{"name": "sensor", "on": true, "none": nil}), map[string]interface{}{
+			"name": "sensor", "on": true, "none": nil,
+		}},
+		{"numbers", cborEncoded(t, []interface{}{uint64(1), int64(-2), float32(0.5), 1.25}), []interface{}{
+			float64(1), float64(-2), 0.5, 1.25,
+		}},
+		{"integer keys", cborEncoded(t, map[int]string{1: "a"}), map[string]interface{}{"1": "a"}},
+		{"byte strings", cborEncoded(t, map[string]interface{}{"payload": []byte{0xde, 0xad, 0xbe, 0xef}}), map[string]interface{}{
+			"payload": "3q2+7w==",
+		}},
+		{"nested", cborEncoded(t, map[string]interface{}{"readings": []interface{}{map[string]interface{}{"t": 21}}}), map[string]interface{}{
+			"readings": []interface{}{map[string]interface{}{"t": float64(21)}},
+		}},
+		{"date", cborEncoded(t, cbor.Tag{Number: 0, Content: "2020-01-02T03:04:05Z"}), "2020-01-02T03:04:05Z"},
+		{"epoch date", cborEncoded(t, cbor.Tag{Number: 1, Content: 0}), time.Unix(0, 0).Format(time.RFC3339Nano)},
+		{"bignum", cborEncoded(t, bignum), 18446744073709551616.0},
+		{"several items", cborEncoded(t, 1, "two"), []interface{}{float64(1), "two"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			object, err := unmarshalCBOR(test.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(object, test.expected) {
+				t.Errorf("expected %#v, got %#v", test.expected, object)
+			}
+		})
+	}
+}
+
+func TestUnmarshalCBORErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []byte
+	}{
+		{"unsupported tag", cborEncoded(t, cbor.Tag{Number: 12345, Content: "x"})},
+		{"truncated", cborEncoded(t, "text")[:2]},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := unmarshalCBOR(test.input); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"github.com/atotto/clipboard"
+	"github.com/sirupsen/logrus"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+var (
+	fromClipboard bool
+	toClipboard   bool
+)
+
+// errNoClipboard explains the failures on the headless systems.
+var errNoClipboard = exitError("no clipboard available, it requires pbcopy, clip.exe, xclip, xsel or wl-clipboard and a display", exitFailure)
+
+// readClipboard reads the --from-clipboard input.
+func readClipboard() (io.ReadCloser, error) {
+	if clipboard.Unsupported {
+		return nil, errNoClipboard
+	}
+	logrus.Debug("reading the clipboard")
+	content, err := clipboard.ReadAll()
+	if err != nil {
+		return nil, exitError("cannot read the clipboard: "+err.Error(), exitFailure)
+	}
+	return ioutil.NopCloser(strings.NewReader(content)), nil
+}
+
+// writeClipboard writes the --to-clipboard output.
+func writeClipboard(content []byte) error {
+	if clipboard.Unsupported {
+		return errNoClipboard
+	}
+	logrus.Debug("writing to the clipboard")
+	if err := clipboard.WriteAll(string(content)); err != nil {
+		return exitError("cannot write the clipboard: "+err.Error(), exitFailure)
+	}
+	return nil
+}
+
+// streamClipboard collects the streamed output for the clipboard.
+func streamClipboard(write func(io.Writer) error) error {
+	var output bytes.Buffer
+	if err := write(&output); err != nil {
+		return err
+	}
+	return writeClipboard(output.Bytes())
+}
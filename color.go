@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+	"golang.org/x/crypto/ssh/terminal"
+	"io"
+	"os"
+)
+
+const colorError = "\x1b[31m"
+
+// setupColor configures the colors of the debug logs and the error messages
+// for the global --color flag: always, never, or auto when stderr is a terminal.
+func setupColor(mode string) error {
+	var colored bool
+	switch mode {
+	case "always":
+		colored = true
+	case "never":
+		colored = false
+	case "auto":
+		colored = terminal.IsTerminal(int(os.Stderr.Fd()))
+	default:
+		return usageError(fmt.Sprintf("invalid --color %q, expected 'always', 'never' or 'auto'", mode))
+	}
+	logrus.SetFormatter(&logrus.TextFormatter{ForceColors: colored, DisableColors: !colored})
+	if colored {
+		cli.ErrWriter = colorWriter{os.Stderr}
+	}
+	return nil
+}
+
+// colorWriter writes the error messages in red, resetting the color
+// before their final newline.
+type colorWriter struct {
+	writer io.Writer
+}
+
+func (w colorWriter) Write(p []byte) (int, error) {
+	text := bytes.TrimSuffix(p, []byte("\n"))
+	if _, err := fmt.Fprintf(w.writer, "%s%s%s%s", colorError, text, colorReset, p[len(text):]); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"github.com/urfave/cli"
+	yamlv3 "gopkg.in/yaml.v3"
+	"io"
+)
+
+var preserveComments bool
+
+var preserveCommentsFlag = cli.BoolFlag{
+	Name:        "preserve-comments",
+	Usage:       "keep the comments and the key order of the YAML, only reformatting it, which rules out the filters",
+	Destination: &preserveComments,
+}
+
+// commentFormat decodes the YAML documents into yaml.v3 nodes, which keep the
+// head, line and foot comments, and encodes them back, so that reformatting
+// YAML loses nothing. The nodes are not the objects the filters work on.
+var commentFormat = format{unmarshal: unmarshalYAMLNodes, marshal: marshalYAMLNodes, separator: yamlDocumentMarker}
+
+// yamlNodes are the documents decoded by unmarshalYAMLNodes.
+type yamlNodes []*yamlv3.Node
+
+func unmarshalYAMLNodes(input []byte) (interface{}, error) {
+	var documents yamlNodes
+	decoder := yamlv3.NewDecoder(bytes.NewReader(input))
+	for {
+		var document yamlv3.Node
+		err := decoder.Decode(&document)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		documents = append(documents, &document)
+	}
+	if len(documents) == 0 {
+		return nil, nil
+	}
+	return documents, nil
+}
+
+func marshalYAMLNodes(object interface{}) ([]byte, error) {
+	var output bytes.Buffer
+	if docMarkers {
+		output.WriteString(yamlDocumentMarker)
+	}
+	encoder := yamlv3.NewEncoder(&output)
+	encoder.SetIndent(2)
+	for _, document := range object.(yamlNodes) {
+		if anchors == anchorsPreserve {
+			untagMergeKeys(document)
+		} else {
+			var err error
+			if document, err = expandAliases(document); err != nil {
+				return nil, err
+			}
+		}
+		if err := encoder.Encode(document); err != nil {
+			return nil, err
+		}
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return output.Bytes(), nil
+}
+
+// checkPreserveComments rejects the options that need the decoded objects,
+// which --preserve-comments and --anchors preserve do not provide.
+func checkPreserveComments(option string) error {
+	filtered := len(jsonpathTemplates) > 0 || jsonpathFile != "" || jqExpression != "" || selectPath != "" ||
+		len(whereExpressions) > 0 || fieldSelector != "" || len(setValues) > 0 || len(setStringValues) > 0 || pickList != "" || omitList != "" || wrapKey != "" || pipeSpec != "" ||
+		patchPath != "" || schemaPath != "" || templateText != "" || templateFile != "" || rawInput ||
+		countResults || stripNulls || stripEmpty || normalizeQuantities || withTypes || sortKeys || decodeBase64 || encodeBase64 || concatInputs || explode || quoteStyle != "" && quoteStyle != "plain"
+	if filtered {
+		return usageError(option + " only reformats the YAML, it cannot be combined with the options that filter or change the documents")
+	}
+	return nil
+}
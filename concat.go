@@ -0,0 +1,26 @@
+package main
+
+var concatInputs bool
+
+// transformConcat decodes every input into one element of a single array,
+// in the order of the inputs, and renders that array as one document.
+// An empty input is kept as a null element, so that the elements
+// still match the inputs.
+func transformConcat(paths []string, from, to format) error {
+	objects := make([]interface{}, 0, len(paths))
+	for _, path := range paths {
+		object, err := unmarshalPath(path, from.unmarshal)
+		if err != nil {
+			return err
+		}
+		objects = append(objects, object)
+	}
+	content, err := render(objects, to.marshal)
+	if err != nil {
+		return err
+	}
+	if content == nil {
+		return emptyResult()
+	}
+	return writeOutput(outputPath, content)
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/urfave/cli"
+	"sort"
+	"strconv"
+	"unicode/utf8"
+)
+
+var csvFormat = format{unmarshal: unmarshalCSV, marshal: marshalCSV, separator: "\n", stringValues: true}
+
+var (
+	csvDelimiter string
+	csvNoHeader  bool
+)
+
+// csvFlags are the flags of the commands reading or writing CSV.
+var csvFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:        "delimiter",
+		Usage:       "the field delimiter, a single character or 'tab'",
+		Value:       ",",
+		Destination: &csvDelimiter,
+	},
+}
+
+var csvNoHeaderFlag = cli.BoolFlag{
+	Name:        "no-header",
+	Usage:       "treat the first row as data and name the columns col0, col1, ...",
+	Destination: &csvNoHeader,
+}
+
+// csvDelimiterRune translates the --delimiter flag into the rune used by the CSV reader and writer.
+func csvDelimiterRune() (rune, error) {
+	if csvDelimiter == "tab" || csvDelimiter == "\\t" {
+		return '\t', nil
+	}
+	delimiter, size := utf8.DecodeRuneInString(csvDelimiter)
+	if size == 0 || size != len(csvDelimiter) || delimiter == utf8.RuneError {
+		return 0, usageError(fmt.Sprintf("invalid delimiter %q, expected a single character", csvDelimiter))
+	}
+	return delimiter, nil
+}
+
+// unmarshalCSV decodes the records into an array of objects keyed by the
+// header row, or by col0, col1, ... when there is no header.
+func unmarshalCSV(input []byte) (interface{}, error) {
+	delimiter, err := csvDelimiterRune()
+	if err != nil {
+		return nil, err
+	}
+	reader := csv.NewReader(bytes.NewReader(input))
+	reader.Comma = delimiter
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	var header []string
+	if !csvNoHeader {
+		header, records = records[0], records[1:]
+	}
+	rows := make([]interface{}, 0, len(records))
+	for _, record := range records {
+		row := map[string]interface{}{}
+		for i, name := range header {
+			if i < len(record) {
+				row[name] = record[i]
+			} else {
+				row[name] = ""
+			}
+		}
+		for i := len(header); i < len(record); i++ {
+			row[fmt.Sprintf("col%d", i)] = record[i]
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// marshalCSV writes an array of objects as CSV, the header being the
+// sorted union of the keys of all the objects.
+func marshalCSV(object interface{}) ([]byte, error) {
+	delimiter, err := csvDelimiterRune()
+	if err != nil {
+		return nil, err
+	}
+	items, ok := object.([]interface{})
+	if !ok {
+		return nil, errors.New("CSV output requires a top-level array of objects")
+	}
+	rows := make([]map[string]interface{}, len(items))
+	keys := map[string]bool{}
+	for i, item := range items {
+		row, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("CSV output requires a top-level array of objects, element %d is not an object", i)
+		}
+		for key := range row {
+			keys[key] = true
+		}
+		rows[i] = row
+	}
+	header := make([]string, 0, len(keys))
+	for key := range keys {
+		header = append(header, key)
+	}
+	sort.Strings(header)
+
+	var output bytes.Buffer
+	writer := csv.NewWriter(&output)
+	writer.Comma = delimiter
+	if err := writer.Write(header); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		record := make([]string, len(header))
+		for i, key := range header {
+			field, err := csvField(row[key])
+			if err != nil {
+				return nil, err
+			}
+			record[i] = field
+		}
+		if err := writer.Write(record); err != nil {
+			return nil, err
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, err
+	}
+	return output.Bytes(), nil
+}
+
+// csvField formats a single value, nested objects and arrays are embedded as JSON.
+func csvField(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	default:
+		field, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		return string(field), nil
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+)
+
+var maxDepth int
+
+// checkDepth fails when the decoded input nests more than --max-depth
+// objects and arrays, to reject the hostile inputs early.
+func checkDepth(inputPath string, object interface{}) error {
+	if maxDepth <= 0 || !exceedsDepth(object, maxDepth) {
+		return nil
+	}
+	return exitError(fmt.Sprintf("%s nests deeper than --max-depth %d", inputName(inputPath), maxDepth), exitFailure)
+}
+
+// exceedsDepth reports whether the value nests more than the remaining
+// levels of objects and arrays, stopping at the first one found.
+func exceedsDepth(value interface{}, remaining int) bool {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if remaining == 0 {
+			return true
+		}
+		for _, item := range v {
+			if exceedsDepth(item, remaining-1) {
+				return true
+			}
+		}
+	case []interface{}:
+		if remaining == 0 {
+			return true
+		}
+		for _, item := range v {
+			if exceedsDepth(item, remaining-1) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckDepth(t *testing.T) {
+	tests := []struct {
+		name     string
+		maxDepth int
+		input    string
+		fails    bool
+	}{
+		{"unlimited", 0, `{"a":{"b":{"c":[1]}}}`, false},
+		{"scalar", 1, `1`, false},
+		{"flat object", 1, `{"a":1}`, false},
+		{"nested object", 1, `{"a":{"b":1}}`, true},
+		{"at the limit", 3, `{"a":{"b":[1]}}`, false},
+		{"beyond the limit", 2, `{"a":{"b":[1]}}`, true},
+		{"empty nested array", 1, `[[]]`, true},
+		{"deep array", 10, strings.Repeat("[", 11) + strings.Repeat("]", 11), true},
+		{"deepest sibling beyond the limit", 2, `[[1],[2],{"a":[3]}]`, true},
+	}
+	defer func(depth int) { maxDepth = depth }(maxDepth)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			maxDepth = test.maxDepth
+			object, err := unmarshalJSON([]byte(test.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = checkDepth("input.json", object)
+			if fails := err != nil; fails != test.fails {
+				t.Errorf("expected failing %v, got %v", test.fails, err)
+			}
+			if err != nil && !strings.Contains(err.Error(), "input.json nests deeper than --max-depth") {
+				t.Errorf("unexpected error %v", err)
+			}
+		})
+	}
+}
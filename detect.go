@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"path/filepath"
+	"strings"
+)
+
+// autoFormat detects the format of every input from its content.
+var autoFormat = format{unmarshal: unmarshalAuto}
+
+// detectedFormats are tried in order by unmarshalAuto. JSON comes first
+// since it is the strictest and the fastest to rule out, and YAML last
+// since almost any text is valid YAML.
+var detectedFormats = []string{"json", "xml", "toml", "yaml"}
+
+// unmarshalAuto decodes the input with the first of the detectedFormats
+// that reads it, listing why every one of them failed otherwise.
+func unmarshalAuto(input []byte) (interface{}, error) {
+	var attempts []string
+	for _, name := range detectedFormats {
+		var object interface{}
+		var err error
+		if name == "xml" && !bytes.HasPrefix(bytes.TrimSpace(input), []byte("<")) {
+			// the XML decoder accepts plain text as character data
+			err = errors.New("does not start with an element")
+		} else {
+			object, err = formats[name].unmarshal(input)
+		}
+		if err == nil {
+			logrus.Debugf("detected the %s format", name)
+			return object, nil
+		}
+		attempts = append(attempts, fmt.Sprintf("%s: %v", name, err))
+	}
+	return nil, fmt.Errorf("could not detect the format, tried\n  %s", strings.Join(attempts, "\n  "))
+}
+
+// detectFormat returns the format of the extension all the input paths share,
+// or autoFormat when they have none, or different ones, or read stdin.
+func detectFormat(paths []string) format {
+	name := ""
+	for _, path := range paths {
+		extension := formatExtensions[strings.ToLower(filepath.Ext(strings.TrimSuffix(path, ".gz")))]
+		if extension == "" || name != "" && extension != name {
+			return autoFormat
+		}
+		name = extension
+	}
+	if name == "" {
+		return autoFormat
+	}
+	logrus.Debugf("reading the %s format of the input extensions", name)
+	return formats[name]
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"github.com/theory/jsonpath"
+)
+
+const (
+	jsonpathKubectl  = "kubectl"
+	jsonpathStandard = "standard"
+)
+
+// jsonpathDialect picks the engine of the JSONPath templates: the kubectl one
+// of client-go, with its {} templates and range blocks, or the RFC 9535 one.
+var jsonpathDialect = jsonpathKubectl
+
+// checkJSONPathDialect validates --jsonpath-dialect, and parses the templates
+// of the standard dialect, so that a wrong one is a usage error.
+func checkJSONPathDialect() error {
+	switch jsonpathDialect {
+	case jsonpathKubectl:
+		return nil
+	case jsonpathStandard:
+		for _, template := range jsonpathTemplates {
+			if _, err := jsonpathStandardQuery(template); err != nil {
+				return usageError(fmt.Sprintf("invalid JSONPath %q: %v", template, err))
+			}
+		}
+		return nil
+	}
+	return usageError(fmt.Sprintf("invalid --jsonpath-dialect %q, expected 'kubectl' or 'standard'", jsonpathDialect))
+}
+
+func jsonpathStandardQuery(query string) (*jsonpath.Path, error) {
+	return jsonpath.Parse(query)
+}
+
+// filterStandard is filter with an RFC 9535 JSONPath query, e.g. $.items[*].metadata.name.
+// Like the kubectl engine, a single match is the value itself and several
+// matches are jsonpathResults.
+func filterStandard(object interface{}, query string) (interface{}, error) {
+	path, err := jsonpathStandardQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	nodes := path.Select(object)
+	if len(nodes) == 0 {
+		return nil, nil
+	} else if len(nodes) == 1 {
+		return nodes[0], nil
+	}
+	return jsonpathResults(nodes), nil
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// formatExtensions maps the file extensions to the format names of formats.
+var formatExtensions = map[string]string{
+	".yaml":       "yaml",
+	".yml":        "yaml",
+	".json":       "json",
+	".toml":       "toml",
+	".xml":        "xml",
+	".csv":        "csv",
+	".ini":        "ini",
+	".env":        "env",
+	".hcl":        "hcl",
+	".tf":         "hcl",
+	".properties": "properties",
+	".msgpack":    "msgpack",
+	".cbor":       "cbor",
+}
+
+// formatForPath returns the --from format, or the format of the path's
+// extension, YAML being the default since it also reads JSON.
+func formatForPath(path string) (format, error) {
+	name := fromFormat
+	if name == "" {
+		name = formatExtensions[strings.ToLower(filepath.Ext(strings.TrimSuffix(path, ".gz")))]
+	}
+	if name == "" {
+		name = "yaml"
+	}
+	from, ok := formats[name]
+	if !ok || from.unmarshal == nil {
+		return format{}, usageError(fmt.Sprintf("cannot read the %q format", name))
+	}
+	return from, nil
+}
+
+// diff writes the structural differences between the two inputs,
+// failing with exit code 1 when they differ.
+func diff() error {
+	paths, err := expandInputs(inputPaths)
+	if err != nil {
+		return err
+	}
+	if len(paths) != 2 {
+		return usageError("diff expects exactly two inputs")
+	}
+	var objects [2]interface{}
+	for i, path := range paths {
+		from, err := formatForPath(path)
+		if err != nil {
+			return err
+		}
+		content, err := readInput(path)
+		if err != nil {
+			return err
+		}
+		if objects[i], err = from.unmarshal(content); err != nil {
+			return fmt.Errorf("cannot parse %s: %v", inputName(path), err)
+		}
+	}
+
+	var lines []string
+	diffValues(objects[0], objects[1], "", &lines)
+	if len(lines) == 0 {
+		return nil
+	}
+	if err := writeOutput(outputPath, []byte(strings.Join(lines, "\n")+"\n")); err != nil {
+		return err
+	}
+	return exitError("", exitFailure)
+}
+
+// diffValues appends the differences between the old and the new value,
+// '-' lines for the old values and '+' lines for the new ones.
+func diffValues(old, new interface{}, path string, lines *[]string) {
+	oldObject, oldIsObject := old.(map[string]interface{})
+	newObject, newIsObject := new.(map[string]interface{})
+	if oldIsObject && newIsObject {
+		keys := make([]string, 0, len(oldObject)+len(newObject))
+		for key := range oldObject {
+			keys = append(keys, key)
+		}
+		for key := range newObject {
+			if _, ok := oldObject[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			child := key
+			if path != "" {
+				child = path + "." + key
+			}
+			oldValue, inOld := oldObject[key]
+			newValue, inNew := newObject[key]
+			switch {
+			case !inOld:
+				*lines = append(*lines, diffLine('+', child, newValue))
+			case !inNew:
+				*lines = append(*lines, diffLine('-', child, oldValue))
+			default:
+				diffValues(oldValue, newValue, child, lines)
+			}
+		}
+		return
+	}
+	oldItems, oldIsArray := old.([]interface{})
+	newItems, newIsArray := new.([]interface{})
+	if oldIsArray && newIsArray {
+		for i := 0; i < len(oldItems) || i < len(newItems); i++ {
+			child := fmt.Sprintf("%s[%d]", path, i)
+			switch {
+			case i >= len(oldItems):
+				*lines = append(*lines, diffLine('+', child, newItems[i]))
+			case i >= len(newItems):
+				*lines = append(*lines, diffLine('-', child, oldItems[i]))
+			default:
+				diffValues(oldItems[i], newItems[i], child, lines)
+			}
+		}
+		return
+	}
+	if !jsonEqual(old, new) {
+		*lines = append(*lines, diffLine('-', path, old), diffLine('+', path, new))
+	}
+}
+
+func diffLine(sign byte, path string, value interface{}) string {
+	if path == "" {
+		path = "."
+	}
+	encoded, _ := json.Marshal(value)
+	return fmt.Sprintf("%c %s: %s", sign, path, encoded)
+}
+
+// jsonEqual compares the values by their JSON encoding,
+// so that the numbers of the different decoders compare equal.
+func jsonEqual(a, b interface{}) bool {
+	encodedA, errA := json.Marshal(a)
+	encodedB, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(encodedA, encodedB)
+}
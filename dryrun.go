@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"github.com/pmezard/go-difflib/difflib"
+	"os"
+)
+
+var (
+	dryRun     bool
+	failOnDiff bool
+)
+
+// previewWrite writes the unified diff between the file and the content
+// --write would replace it with, and reports whether they differ.
+func previewWrite(path string, content []byte) (bool, error) {
+	content = withTrailingNewline(content)
+	current, err := readInput(path)
+	if err != nil {
+		return false, err
+	}
+	if bytes.Equal(current, content) {
+		return false, nil
+	}
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        diffLines(current),
+		B:        diffLines(content),
+		FromFile: path,
+		ToFile:   path,
+		Context:  3,
+	})
+	if err != nil {
+		return false, err
+	}
+	_, err = os.Stdout.WriteString(diff)
+	return true, err
+}
+
+// diffLines is difflib.SplitLines without the empty line it adds after the last newline.
+func diffLines(content []byte) []string {
+	lines := difflib.SplitLines(string(content))
+	if bytes.HasSuffix(content, []byte("\n")) {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
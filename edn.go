@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"olympos.io/encoding/edn"
+	"sort"
+	"strings"
+	"time"
+)
+
+var ednFormat = format{unmarshal: unmarshalEDN}
+
+// unmarshalEDN decodes the EDN forms of the input, several top-level forms
+// being handled like the documents of a YAML stream. The conversion is
+// lossy: keywords and symbols become strings without their leading colon,
+// lists and vectors arrays, sets sorted arrays, characters strings, #inst
+// an RFC 3339 string, and the other tagged literals their bare value.
+func unmarshalEDN(input []byte) (interface{}, error) {
+	var objects []interface{}
+	decoder := edn.NewDecoder(bytes.NewReader(input))
+	for {
+		var value interface{}
+		err := decoder.Decode(&value)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		object, err := ednToJSON(value)
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, object)
+	}
+
+	if len(objects) == 0 {
+		return nil, nil
+	} else if len(objects) == 1 {
+		return objects[0], nil
+	} else {
+		return objects, nil
+	}
+}
+
+// ednToJSON converts the decoded EDN into the same structure the JSON decoder
+// produces. The integers stay integers with --preserve-int.
+func ednToJSON(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case nil, bool, string, float64:
+		return v, nil
+	case int64:
+		if preserveInt {
+			return preservedInt(v), nil
+		}
+		return float64(v), nil
+	case *big.Int:
+		return ednBigInt(v), nil
+	case big.Int:
+		return ednBigInt(&v), nil
+	case *big.Float:
+		number, _ := v.Float64()
+		return number, nil
+	case big.Float:
+		number, _ := v.Float64()
+		return number, nil
+	case edn.Keyword:
+		return strings.TrimPrefix(string(v), ":"), nil
+	case edn.Symbol:
+		return string(v), nil
+	case edn.Rune:
+		return string(rune(v)), nil
+	case rune:
+		// the characters are decoded as runes
+		return string(v), nil
+	case time.Time:
+		return v.Format(time.RFC3339Nano), nil
+	case edn.Tag:
+		return ednToJSON(v.Value)
+	case *interface{}:
+		// the map keys and set elements that are not comparable, like vectors
+		return ednToJSON(*v)
+	case []interface{}:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			converted, err := ednToJSON(item)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = converted
+		}
+		return items, nil
+	case map[interface{}]bool:
+		return ednSet(v)
+	case map[interface{}]interface{}:
+		object := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			name, err := ednKey(key)
+			if err != nil {
+				return nil, err
+			}
+			converted, err := ednToJSON(item)
+			if err != nil {
+				return nil, err
+			}
+			object[name] = converted
+		}
+		return object, nil
+	default:
+		return nil, fmt.Errorf("unsupported EDN value of type %T", value)
+	}
+}
+
+// ednBigInt converts an integer of the N suffix to a float64, or to an int
+// with --preserve-int when it fits in one.
+func ednBigInt(v *big.Int) interface{} {
+	if preserveInt && v.IsInt64() {
+		return preservedInt(v.Int64())
+	}
+	number, _ := new(big.Float).SetInt(v).Float64()
+	return number
+}
+
+// ednSet converts a set into an array, sorted by the JSON encoding
+// of its elements so that the output is stable.
+func ednSet(set map[interface{}]bool) (interface{}, error) {
+	items := make([]interface{}, 0, len(set))
+	encoded := map[int]string{}
+	for element := range set {
+		converted, err := ednToJSON(element)
+		if err != nil {
+			return nil, err
+		}
+		text, err := json.Marshal(converted)
+		if err != nil {
+			return nil, err
+		}
+		encoded[len(items)] = string(text)
+		items = append(items, converted)
+	}
+	order := make([]int, len(items))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool { return encoded[order[i]] < encoded[order[j]] })
+	sorted := make([]interface{}, len(items))
+	for i, index := range order {
+		sorted[i] = items[index]
+	}
+	return sorted, nil
+}
+
+// ednKey names the key of a map: strings, keywords and symbols as they
+// are, and the other keys by their JSON encoding.
+func ednKey(key interface{}) (string, error) {
+	converted, err := ednToJSON(key)
+	if err != nil {
+		return "", err
+	}
+	if name, ok := converted.(string); ok {
+		return name, nil
+	}
+	text, err := json.Marshal(converted)
+	return string(text), err
+}
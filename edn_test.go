@@ -0,0 +1,51 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUnmarshalEDN(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected interface{}
+	}{
+		{"empty", "", nil},
+		{"map", `{:name "2fy" :port 8080 :ratio 0.5 :on true :none nil}`, map[string]interface{}{
+			"name": "2fy", "port": float64(8080), "ratio": 0.5, "on": true, "none": nil,
+		}},
+		{"keywords and symbols", `[:a/b sym]`, []interface{}{"a/b", "sym"}},
+		{"lists and vectors", `{:list (1 2) :vector [3 4]}`, map[string]interface{}{
+			"list": []interface{}{float64(1), float64(2)}, "vector": []interface{}{float64(3), float64(4)},
+		}},
+		{"sets", `#{3 1 2}`, []interface{}{float64(1), float64(2), float64(3)}},
+		{"set of vectors", `#{[2] [1]}`, []interface{}{[]interface{}{float64(1)}, []interface{}{float64(2)}}},
+		{"characters", `[\a \newline]`, []interface{}{"a", "\n"}},
+		{"instants", `#inst "1985-04-12T23:20:50.52Z"`, "1985-04-12T23:20:50.52Z"},
+		{"tagged literals", `#myapp/person {:name "x"}`, map[string]interface{}{"name": "x"}},
+		{"big numbers", `[12345678901234567890N 1.5M]`, []interface{}{12345678901234567890.0, 1.5}},
+		{"other keys", `{1 :a [1 2] :b}`, map[string]interface{}{"1": "a", "[1,2]": "b"}},
+		{"comments and discards", "; comment\n[1 #_2 3]", []interface{}{float64(1), float64(3)}},
+		{"several forms", `{:a 1} {:b 2}`, []interface{}{
+			map[string]interface{}{"a": float64(1)}, map[string]interface{}{"b": float64(2)},
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			object, err := unmarshalEDN([]byte(test.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(object, test.expected) {
+				t.Errorf("expected %#v, got %#v", test.expected, object)
+			}
+		})
+	}
+}
+
+func TestUnmarshalEDNError(t *testing.T) {
+	if _, err := unmarshalEDN([]byte(`{:a 1`)); err == nil {
+		t.Error("expected an error for the unterminated map")
+	}
+}
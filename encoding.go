@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/text/encoding/unicode"
+	"io"
+	"strings"
+)
+
+var inputEncoding string
+
+var utf8BOM = []byte{0xef, 0xbb, 0xbf}
+
+// decodeInput strips the UTF-8 byte order mark some Windows tools prepend,
+// or transcodes the UTF-16 input of --input-encoding into UTF-8. The UTF-8
+// input is left untouched otherwise, since it may be binary.
+func decodeInput(input io.ReadCloser) (io.ReadCloser, error) {
+	var endianness unicode.Endianness
+	switch strings.ToLower(inputEncoding) {
+	case "", "utf-8", "utf8":
+		buffered := bufio.NewReader(input)
+		if bom, _ := buffered.Peek(len(utf8BOM)); bytes.Equal(bom, utf8BOM) {
+			logrus.Debug("stripping the UTF-8 byte order mark")
+			buffered.Discard(len(utf8BOM))
+		}
+		return struct {
+			io.Reader
+			io.Closer
+		}{buffered, input}, nil
+	case "utf-16le", "utf16le":
+		endianness = unicode.LittleEndian
+	case "utf-16be", "utf16be":
+		endianness = unicode.BigEndian
+	default:
+		input.Close()
+		return nil, usageError(fmt.Sprintf("unsupported --input-encoding %q, expected 'utf-8', 'utf-16le' or 'utf-16be'", inputEncoding))
+	}
+	logrus.Debugf("transcoding the %v input to UTF-8", inputEncoding)
+	// UseBOM strips the byte order mark, and follows it when it disagrees
+	decoder := unicode.UTF16(endianness, unicode.UseBOM).NewDecoder()
+	return struct {
+		io.Reader
+		io.Closer
+	}{decoder.Reader(input), input}, nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestDecodeInput(t *testing.T) {
+	utf16le, err := ioutil.ReadFile("testdata/utf16le.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		encoding string
+		input    string
+		expected string
+	}{
+		{"utf-8", "", "name: 2fy\n", "name: 2fy\n"},
+		{"utf-8 byte order mark", "utf-8", "\xef\xbb\xbfname: 2fy\n", "name: 2fy\n"},
+		{"utf-8 binary", "", "\xff\xfe\x00", "\xff\xfe\x00"},
+		{"utf-16le file", "utf-16le", string(utf16le), "name: 2fy\nlist:\n- é\n"},
+		{"utf-16be", "UTF-16BE", "\x00a\x00:\x00 \x001", "a: 1"},
+		{"utf-16be byte order mark", "utf16be", "\xfe\xff\x00a\x00:\x00 \x001", "a: 1"},
+		{"utf-16le byte order mark with utf-16be", "utf-16be", "\xff\xfea\x00:\x00 \x001\x00", "a: 1"},
+	}
+	defer func(encoding string) { inputEncoding = encoding }(inputEncoding)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			inputEncoding = test.encoding
+			decoded, err := decodeInput(ioutil.NopCloser(strings.NewReader(test.input)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			output, err := ioutil.ReadAll(decoded)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(output) != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, output)
+			}
+		})
+	}
+}
+
+func TestDecodeInputUnsupportedEncoding(t *testing.T) {
+	defer func(encoding string) { inputEncoding = encoding }(inputEncoding)
+	inputEncoding = "latin1"
+	if _, err := decodeInput(ioutil.NopCloser(strings.NewReader("a: 1"))); err == nil {
+		t.Error("expected an error for --input-encoding latin1")
+	}
+}
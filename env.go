@@ -0,0 +1,255 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/urfave/cli"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var envFormat = format{unmarshal: unmarshalEnv, marshal: marshalEnv, separator: "\n", stringValues: true}
+
+var shellFormat = format{marshal: marshalShell, separator: "\n"}
+
+var envFlatten bool
+
+// envKeyPattern matches the variable names, which the dotenv keys must be
+// for the shells and the dotenv loaders to read them back.
+var envKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+var envFlattenFlag = cli.BoolFlag{
+	Name:        "flatten",
+	Usage:       "join the keys of nested objects and arrays with underscores",
+	Destination: &envFlatten,
+}
+
+// unmarshalEnv decodes a dotenv file of KEY=VALUE lines into a flat map.
+// Blank lines and # comments are skipped and an 'export ' prefix is ignored.
+func unmarshalEnv(input []byte) (interface{}, error) {
+	object := map[string]interface{}{}
+	scanner := bufio.NewScanner(bytes.NewReader(input))
+	for number := 1; scanner.Scan(); number++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		separator := strings.Index(line, "=")
+		if separator < 0 {
+			return nil, fmt.Errorf("line %d: expected KEY=VALUE, got %q", number, line)
+		}
+		key := strings.TrimSpace(line[:separator])
+		if key == "" {
+			return nil, fmt.Errorf("line %d: missing key", number)
+		}
+		value, err := parseEnvValue(strings.TrimSpace(line[separator+1:]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", number, err)
+		}
+		object[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(object) == 0 {
+		return nil, nil
+	}
+	return object, nil
+}
+
+// parseEnvValue unquotes a value: double quoted values support backslash
+// escapes, single quoted values are literal and unquoted values end at
+// an inline # comment.
+func parseEnvValue(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, `"`):
+		var unquoted strings.Builder
+		for i := 1; i < len(value); i++ {
+			c := value[i]
+			if c == '"' {
+				return unquoted.String(), nil
+			}
+			if c == '\\' && i+1 < len(value) {
+				i++
+				switch value[i] {
+				case 'n':
+					unquoted.WriteByte('\n')
+				case 'r':
+					unquoted.WriteByte('\r')
+				case 't':
+					unquoted.WriteByte('\t')
+				default:
+					unquoted.WriteByte(value[i])
+				}
+				continue
+			}
+			unquoted.WriteByte(c)
+		}
+		return "", fmt.Errorf("unterminated double quoted value %s", value)
+	case strings.HasPrefix(value, "'"):
+		end := strings.Index(value[1:], "'")
+		if end < 0 {
+			return "", fmt.Errorf("unterminated single quoted value %s", value)
+		}
+		return value[1 : end+1], nil
+	default:
+		if comment := strings.Index(value, " #"); comment >= 0 {
+			value = value[:comment]
+		}
+		return strings.TrimSpace(value), nil
+	}
+}
+
+// marshalEnv writes a flat object as KEY=VALUE lines sorted by key. Nested
+// objects and arrays are an error unless --flatten is given, in which case
+// their keys are joined to the parent key with underscores. A key that is not
+// a variable name is an error, the shell output of yaml2env sanitizes them instead.
+func marshalEnv(object interface{}) ([]byte, error) {
+	fields, ok := object.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("dotenv output requires a top-level object")
+	}
+	variables := map[string]string{}
+	if err := collectEnv(variables, "", fields); err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(variables))
+	for key := range variables {
+		if !envKeyPattern.MatchString(key) {
+			return nil, fmt.Errorf("cannot write the key %q in dotenv, the keys must be letters, digits and underscores, not starting with a digit", key)
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var output bytes.Buffer
+	for _, key := range keys {
+		fmt.Fprintf(&output, "%s=%s\n", key, quoteEnvValue(variables[key]))
+	}
+	return output.Bytes(), nil
+}
+
+// marshalShell writes the object as 'export KEY=VALUE' lines to eval in a shell,
+// the keys uppercased and their other characters than letters, digits
+// and underscores replaced by underscores.
+func marshalShell(object interface{}) ([]byte, error) {
+	fields, ok := object.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("shell output requires an object, not %s, select one with --jsonpath", jsonKind(object))
+	}
+	collected := map[string]string{}
+	if err := collectEnv(collected, "", fields); err != nil {
+		return nil, err
+	}
+	variables := map[string]string{}
+	names := map[string]string{}
+	for key, value := range collected {
+		name := shellVariable(key)
+		if other, ok := names[name]; ok {
+			return nil, fmt.Errorf("the keys %q and %q are both exported as %s", other, key, name)
+		}
+		names[name] = key
+		variables[name] = value
+	}
+	keys := make([]string, 0, len(variables))
+	for key := range variables {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var output bytes.Buffer
+	for _, key := range keys {
+		fmt.Fprintf(&output, "export %s=%s\n", key, quoteShellValue(variables[key]))
+	}
+	return output.Bytes(), nil
+}
+
+// shellVariable makes a valid shell variable name of the key.
+func shellVariable(key string) string {
+	name := []rune(strings.ToUpper(key))
+	for i, c := range name {
+		if !(c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_') {
+			name[i] = '_'
+		}
+	}
+	if len(name) == 0 || name[0] >= '0' && name[0] <= '9' {
+		return "_" + string(name)
+	}
+	return string(name)
+}
+
+// quoteShellValue single quotes the values with characters the shell would expand or split on.
+func quoteShellValue(value string) string {
+	safe := value != ""
+	for _, c := range value {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.ContainsRune("_-.,:/@%+=", c)) {
+			safe = false
+			break
+		}
+	}
+	if safe {
+		return value
+	}
+	return "'" + strings.Replace(value, "'", `'\''`, -1) + "'"
+}
+
+func collectEnv(variables map[string]string, key string, value interface{}) error {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if key != "" && !envFlatten {
+			return fmt.Errorf("cannot represent the nested object %q in dotenv, only flat objects are supported (use --flatten)", key)
+		}
+		for name, item := range v {
+			if err := collectEnv(variables, joinEnvKey(key, name), item); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		if !envFlatten {
+			return fmt.Errorf("cannot represent the array %q in dotenv, only flat objects are supported (use --flatten)", key)
+		}
+		for i, item := range v {
+			if err := collectEnv(variables, joinEnvKey(key, strconv.Itoa(i)), item); err != nil {
+				return err
+			}
+		}
+	case nil:
+		variables[key] = ""
+	case string:
+		variables[key] = v
+	case float64:
+		variables[key] = strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		variables[key] = fmt.Sprint(v)
+	}
+	return nil
+}
+
+func joinEnvKey(parent, key string) string {
+	if parent == "" {
+		return key
+	}
+	return parent + "_" + key
+}
+
+// quoteEnvValue double quotes the values that would not be read back
+// as they are, escaping the characters that have a meaning in quotes.
+func quoteEnvValue(value string) string {
+	safe := true
+	for _, c := range value {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.ContainsRune("_-.,:/@%+=", c)) {
+			safe = false
+			break
+		}
+	}
+	if safe {
+		return value
+	}
+	replacer := strings.NewReplacer("\\", "\\\\", "\"", "\\\"", "\n", "\\n", "\r", "\\r", "\t", "\\t", "$", "\\$", "`", "\\`")
+	return `"` + replacer.Replace(value) + `"`
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMarshalEnv(t *testing.T) {
+	tests := []struct {
+		name     string
+		flatten  bool
+		input    string
+		expected string
+	}{
+		{"sorted keys", false, `{"PORT":80,"HOST":"localhost"}`, "HOST=localhost\nPORT=80\n"},
+		{"scalars", false, `{"DEBUG":true,"RATIO":0.5,"EMPTY":null,"BIG":1e21}`, "BIG=1000000000000000000000\nDEBUG=true\nEMPTY=\nRATIO=0.5\n"},
+		{"spaces", false, `{"MOTD":"hello world"}`, "MOTD=\"hello world\"\n"},
+		{"special characters", false, `{"A":"say \"hi\"","B":"$HOME","C":"a\\b","D":"#tag"}`, "A=\"say \\\"hi\\\"\"\nB=\"\\$HOME\"\nC=\"a\\\\b\"\nD=\"#tag\"\n"},
+		{"newlines", false, `{"KEY":"line 1\nline 2"}`, "KEY=\"line 1\\nline 2\"\n"},
+		{"safe characters", false, `{"URL":"https://example.com/a-b_c.d?"}`, "URL=\"https://example.com/a-b_c.d?\"\n"},
+		{"flatten", true, `{"DB":{"HOST":"localhost","PORTS":[5432,5433]}}`, "DB_HOST=localhost\nDB_PORTS_0=5432\nDB_PORTS_1=5433\n"},
+		{"flatten lowercase", true, `{"db":{"host":"localhost"}}`, "db_host=localhost\n"},
+	}
+	defer func(flatten bool) { envFlatten = flatten }(envFlatten)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			envFlatten = test.flatten
+			output, err := convertText(jsonFormat, envFormat, test.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if output != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, output)
+			}
+		})
+	}
+}
+
+func TestMarshalEnvErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		flatten bool
+		input   string
+	}{
+		{"top-level array", false, `[1]`},
+		{"nested object", false, `{"DB":{"HOST":"localhost"}}`},
+		{"array", false, `{"PORTS":[80]}`},
+		{"key with a space", false, `{"a b":1}`},
+		{"key with a dash", false, `{"a-b":1}`},
+		{"key starting with a digit", false, `{"1A":1}`},
+		{"empty key", false, `{"":1}`},
+		{"flattened key with a dot", true, `{"db":{"a.b":1}}`},
+	}
+	defer func(flatten bool) { envFlatten = flatten }(envFlatten)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			envFlatten = test.flatten
+			if _, err := convertText(jsonFormat, envFormat, test.input); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestEnvRoundTrip(t *testing.T) {
+	object := map[string]interface{}{
+		"PLAIN":   "value",
+		"SPACES":  "hello world",
+		"QUOTES":  `say "hi" 'there'`,
+		"ESCAPES": "a\\b\n\t$HOME `cmd`",
+		"COMMENT": "a #b",
+		"EMPTY":   "",
+	}
+	output, err := marshalEnv(object)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := unmarshalEnv(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, object) {
+		t.Errorf("expected %#v, got %#v from %s", object, decoded, output)
+	}
+}
@@ -0,0 +1,273 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+var includeOptional bool
+
+// exampleStrings are the placeholders of the string formats.
+var exampleStrings = map[string]string{
+	"date-time": "2006-01-02T15:04:05Z",
+	"date":      "2006-01-02",
+	"time":      "15:04:05Z",
+	"duration":  "PT1H",
+	"email":     "user@example.com",
+	"hostname":  "example.com",
+	"ipv4":      "192.0.2.1",
+	"ipv6":      "2001:db8::1",
+	"uri":       "https://example.com",
+	"url":       "https://example.com",
+	"uuid":      "00000000-0000-0000-0000-000000000000",
+}
+
+// exampleFormat decodes the JSON Schema read like from into an example
+// document of it.
+func exampleFormat(from format) format {
+	return format{
+		unmarshal: func(input []byte) (interface{}, error) {
+			object, err := from.unmarshal(input)
+			if err != nil || object == nil {
+				return object, err
+			}
+			if _, ok := object.(map[string]interface{}); !ok {
+				return nil, fmt.Errorf("a JSON Schema is an object, not %s", jsonKind(object))
+			}
+			return (&exampleSchema{root: object, refs: map[string]bool{}}).example(object)
+		},
+	}
+}
+
+// exampleSchema builds the example of a schema, resolving its $ref
+// against its root. The refs being resolved are tracked to stop the
+// recursive schemas, whose recursion is left out of the example.
+type exampleSchema struct {
+	root interface{}
+	refs map[string]bool
+}
+
+// example is the const, default or first of the examples or enum values of
+// the schema, or else a placeholder value of its type: the properties of the
+// objects, only the required ones unless --include-optional, one item for the
+// arrays, the minimum or 0 for the numbers, false and a string of its format.
+// A $ref recursing into itself is null, and an array of it is empty.
+func (s *exampleSchema) example(value interface{}) (interface{}, error) {
+	schema, ok := value.(map[string]interface{})
+	if !ok {
+		// the true and false schemas
+		return nil, nil
+	}
+	for _, key := range []string{"const", "default"} {
+		if example, ok := schema[key]; ok {
+			return example, nil
+		}
+	}
+	for _, key := range []string{"examples", "enum"} {
+		if values, ok := schema[key].([]interface{}); ok && len(values) > 0 {
+			return values[0], nil
+		}
+	}
+	if example, ok := schema["example"]; ok {
+		// OpenAPI
+		return example, nil
+	}
+	if ref, ok := schema["$ref"].(string); ok {
+		if s.refs[ref] {
+			return nil, nil
+		}
+		target, err := s.resolve(ref)
+		if err != nil {
+			return nil, err
+		}
+		s.refs[ref] = true
+		defer delete(s.refs, ref)
+		return s.example(target)
+	}
+	if all, ok := schema["allOf"].([]interface{}); ok {
+		return s.exampleAll(schema, all)
+	}
+	for _, key := range []string{"oneOf", "anyOf"} {
+		if choices, ok := schema[key].([]interface{}); ok && len(choices) > 0 {
+			return s.example(choices[0])
+		}
+	}
+
+	switch schemaType(schema) {
+	case "object":
+		return s.exampleObject(schema)
+	case "array":
+		items := schema["items"]
+		if prefix, ok := schema["prefixItems"].([]interface{}); ok && len(prefix) > 0 {
+			items = prefix[0]
+		} else if tuple, ok := items.([]interface{}); ok && len(tuple) > 0 {
+			items = tuple[0]
+		}
+		if items == nil {
+			return []interface{}{}, nil
+		}
+		item, err := s.example(items)
+		if err != nil || item == nil {
+			// no example of the items, e.g. of a recursive schema
+			return []interface{}{}, err
+		}
+		return []interface{}{item}, nil
+	case "string":
+		if placeholder, ok := exampleStrings[fmt.Sprint(schema["format"])]; ok {
+			return placeholder, nil
+		}
+		return "string", nil
+	case "integer", "number":
+		if minimum, ok := schema["minimum"].(float64); ok {
+			return minimum, nil
+		}
+		if minimum, ok := schema["exclusiveMinimum"].(float64); ok {
+			if schemaType(schema) == "integer" {
+				return minimum + 1, nil
+			}
+			return minimum + 0.5, nil
+		}
+		return 0.0, nil
+	case "boolean":
+		return false, nil
+	}
+	return nil, nil
+}
+
+func (s *exampleSchema) exampleObject(schema map[string]interface{}) (interface{}, error) {
+	example := map[string]interface{}{}
+	properties, _ := schema["properties"].(map[string]interface{})
+	required := map[string]bool{}
+	var requiredKeys []string
+	if names, ok := schema["required"].([]interface{}); ok {
+		for _, name := range names {
+			required[fmt.Sprint(name)] = true
+			requiredKeys = append(requiredKeys, fmt.Sprint(name))
+		}
+	}
+	var keys []string
+	for _, key := range orderedKeys(properties) {
+		if !required[key] && !includeOptional {
+			continue
+		}
+		value, err := s.example(properties[key])
+		if err != nil {
+			return nil, err
+		}
+		example[key] = value
+		keys = append(keys, key)
+	}
+	// the required properties without a schema
+	sort.Strings(requiredKeys)
+	for _, key := range requiredKeys {
+		if _, ok := example[key]; !ok {
+			example[key] = nil
+			keys = append(keys, key)
+		}
+	}
+	if preserveOrder {
+		recordKeyOrder(example, keys)
+	}
+	return example, nil
+}
+
+// exampleAll merges the object examples of the allOf schemas, and of the
+// schema itself when it has properties too.
+func (s *exampleSchema) exampleAll(schema map[string]interface{}, all []interface{}) (interface{}, error) {
+	rest := map[string]interface{}{}
+	for key, value := range schema {
+		if key != "allOf" {
+			rest[key] = value
+		}
+	}
+	if _, ok := rest["properties"]; ok {
+		all = append(all, rest)
+	}
+	var merged interface{}
+	for _, item := range all {
+		example, err := s.example(item)
+		if err != nil {
+			return nil, err
+		}
+		object, isObject := example.(map[string]interface{})
+		base, isBase := merged.(map[string]interface{})
+		if !isObject || !isBase {
+			if merged == nil {
+				merged = example
+			}
+			continue
+		}
+		for key, value := range object {
+			if _, ok := base[key]; ok {
+				continue
+			}
+			base[key] = value
+			if preserveOrder {
+				recordKeyOrder(base, append(recordedKeys(base), key))
+			}
+		}
+	}
+	return merged, nil
+}
+
+// schemaType is the type of the schema, the first one that is not null of a
+// list of types, or else the one implied by its keywords.
+func schemaType(schema map[string]interface{}) string {
+	switch t := schema["type"].(type) {
+	case string:
+		return t
+	case []interface{}:
+		for _, item := range t {
+			if name := fmt.Sprint(item); name != "null" {
+				return name
+			}
+		}
+		return "null"
+	}
+	if _, ok := schema["properties"]; ok {
+		return "object"
+	}
+	if _, ok := schema["items"]; ok {
+		return "array"
+	}
+	return ""
+}
+
+// resolve looks up a $ref of a JSON pointer in the schema document, like #/$defs/name.
+func (s *exampleSchema) resolve(ref string) (interface{}, error) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, fmt.Errorf("cannot resolve $ref %q, only the references within the schema are supported", ref)
+	}
+	pointer, err := url.PathUnescape(ref[1:])
+	if err != nil {
+		return nil, fmt.Errorf("invalid $ref %q: %v", ref, err)
+	}
+	value := s.root
+	if pointer == "" {
+		return value, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("cannot resolve $ref %q, only JSON pointers are supported", ref)
+	}
+	for _, token := range strings.Split(pointer[1:], "/") {
+		token = strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1)
+		switch v := value.(type) {
+		case map[string]interface{}:
+			var ok bool
+			if value, ok = v[token]; !ok {
+				return nil, fmt.Errorf("cannot resolve $ref %q: no %s", ref, token)
+			}
+		case []interface{}:
+			var index int
+			if _, err := fmt.Sscan(token, &index); err != nil || index < 0 || index >= len(v) {
+				return nil, fmt.Errorf("cannot resolve $ref %q: no index %s", ref, token)
+			}
+			value = v[index]
+		default:
+			return nil, fmt.Errorf("cannot resolve $ref %q: no %s", ref, token)
+		}
+	}
+	return value, nil
+}
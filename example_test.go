@@ -0,0 +1,77 @@
+package main
+
+import "testing"
+
+func TestSchemaExample(t *testing.T) {
+	tests := []struct {
+		name     string
+		optional bool
+		schema   string
+		expected string
+	}{
+		{"types", true, `{"properties":{"s":{"type":"string"},"n":{"type":"number"},"i":{"type":"integer"},"b":{"type":"boolean"},"z":{"type":"null"}}}`,
+			`{"b":false,"i":0,"n":0,"s":"string","z":null}`},
+		{"string formats", true, `{"properties":{"at":{"type":"string","format":"date-time"},"mail":{"type":"string","format":"email"}}}`,
+			`{"at":"2006-01-02T15:04:05Z","mail":"user@example.com"}`},
+		{"minimums", true, `{"properties":{"a":{"type":"integer","minimum":3},"b":{"type":"integer","exclusiveMinimum":3},"c":{"type":"number","exclusiveMinimum":1}}}`,
+			`{"a":3,"b":4,"c":1.5}`},
+		{"nullable type", false, `{"type":["null","string"]}`, `"string"`},
+		{"const", false, `{"type":"string","const":"fixed","default":"other"}`, `"fixed"`},
+		{"default", false, `{"type":"integer","default":8080,"examples":[80]}`, `8080`},
+		{"examples", false, `{"type":"integer","examples":[80,443],"enum":[1]}`, `80`},
+		{"enum", false, `{"enum":["red","green"]}`, `"red"`},
+		{"openapi example", false, `{"type":"string","example":"sample"}`, `"sample"`},
+		{"required only", false, `{"type":"object","required":["a","missing"],"properties":{"a":{"type":"string"},"b":{"type":"string"}}}`,
+			`{"a":"string","missing":null}`},
+		{"optional included", true, `{"type":"object","required":["a"],"properties":{"a":{"type":"string"},"b":{"type":"integer"}}}`,
+			`{"a":"string","b":0}`},
+		{"nested objects and arrays", true, `{"properties":{"spec":{"properties":{"ports":{"type":"array","items":{"properties":{"port":{"type":"integer"}}}}}}}}`,
+			`{"spec":{"ports":[{"port":0}]}}`},
+		{"array without items", false, `{"type":"array"}`, `[]`},
+		{"tuple", false, `{"type":"array","prefixItems":[{"type":"boolean"},{"type":"string"}]}`, `[false]`},
+		{"ref", false, `{"$ref":"#/$defs/port","$defs":{"port":{"type":"integer","minimum":1}}}`, `1`},
+		{"escaped ref", false, `{"$ref":"#/definitions/a~1b","definitions":{"a/b":{"const":"slash"}}}`, `"slash"`},
+		{"recursive ref", true, `{"$ref":"#/$defs/node","$defs":{"node":{"properties":{"name":{"type":"string"},"children":{"type":"array","items":{"$ref":"#/$defs/node"}}}}}}`,
+			`{"children":[],"name":"string"}`},
+		{"all of", true, `{"allOf":[{"properties":{"a":{"type":"string"}}},{"properties":{"a":{"type":"integer"},"b":{"type":"boolean"}}}]}`,
+			`{"a":"string","b":false}`},
+		{"one of", false, `{"oneOf":[{"type":"integer"},{"type":"string"}]}`, `0`},
+		{"empty schema", false, `{}`, ``},
+	}
+	defer func(optional bool) { includeOptional = optional }(includeOptional)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			includeOptional = test.optional
+			output, err := convertText(exampleFormat(jsonFormat), jsonFormat, test.schema)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if output != test.expected {
+				t.Errorf("expected %s, got %s", test.expected, output)
+			}
+		})
+	}
+}
+
+func TestSchemaExampleErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		err    string
+	}{
+		{"not an object", `[1]`, "a JSON Schema is an object, not an array"},
+		{"external ref", `{"$ref":"other.json#/a"}`, `cannot resolve $ref "other.json#/a", only the references within the schema are supported`},
+		{"missing ref", `{"$ref":"#/$defs/missing","$defs":{}}`, `cannot resolve $ref "#/$defs/missing": no missing`},
+		{"missing index", `{"$ref":"#/items/3","items":[{}]}`, `cannot resolve $ref "#/items/3": no index 3`},
+		{"anchor ref", `{"$ref":"#node"}`, `cannot resolve $ref "#node", only JSON pointers are supported`},
+		{"nested error", `{"properties":{"a":{"$ref":"#/nowhere"}},"required":["a"]}`, `cannot resolve $ref "#/nowhere": no nowhere`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := convertText(exampleFormat(jsonFormat), jsonFormat, test.schema)
+			if err == nil || err.Error() != test.err {
+				t.Errorf("expected the error %q, got %v", test.err, err)
+			}
+		})
+	}
+}
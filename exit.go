@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"github.com/urfave/cli"
+)
+
+// The exit codes of 2fy, following the usual CLI conventions so that
+// scripts can tell a wrong command line from an input that cannot be converted.
+const (
+	// exitFailure is returned when an input cannot be read, parsed, converted
+	// or written, and when a check such as --fail-on-empty or diff fails.
+	exitFailure = 1
+	// exitUsage is returned for an unknown command, an undefined or malformed
+	// flag, flags that cannot be combined and invalid flag values.
+	exitUsage = 2
+)
+
+// errorPrefix starts every error message, so that the wrong command lines
+// and the failed conversions are reported alike.
+const errorPrefix = "ERROR: "
+
+// exitError reports the error message, exiting with the code.
+// An empty message exits silently.
+func exitError(message string, code int) error {
+	if message != "" {
+		message = errorPrefix + message
+	}
+	return cli.NewExitError(message, code)
+}
+
+// usageError reports a mistake in the command line, exiting with exitUsage.
+func usageError(message string) error {
+	return exitError(message, exitUsage)
+}
+
+// onUsageError handles the flags the parser rejects, of the application
+// and of every command, exiting with exitUsage instead of the default 1.
+func onUsageError(c *cli.Context, err error, isSubcommand bool) error {
+	fmt.Fprintf(cli.ErrWriter, "%s%v\n", errorPrefix, err)
+	if c.Command.Name != "" {
+		fmt.Fprintln(cli.ErrWriter)
+		cli.ShowCommandHelp(c, c.Command.Name)
+	}
+	return cli.NewExitError("", exitUsage)
+}
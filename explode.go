@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+var (
+	explode   bool
+	nameField string
+)
+
+// transformExplode writes every document of the inputs to its own file of
+// the --output-dir, named doc-0, doc-1... after its index, or after the value
+// of its --name-field, with the --output-suffix appended. A name used
+// by an earlier document gets its index appended, or the next unused number.
+func transformExplode(paths []string, from, to format) error {
+	if outputDir == "" || outputSuffix == "" {
+		return usageError("--explode requires --output-dir and --output-suffix to name the output files")
+	}
+	if outputPath != "" {
+		return usageError("--explode cannot be combined with --output")
+	}
+	var namePath []selectSegment
+	if nameField != "" {
+		var err error
+		if namePath, err = parseSelectPath(nameField); err != nil {
+			return usageError(fmt.Sprintf("invalid --name-field %q: %v", nameField, err))
+		}
+	}
+	if len(paths) == 0 {
+		// no input paths, use stdin
+		paths = []string{""}
+	}
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return err
+	}
+
+	index := 0
+	used := map[string]bool{}
+	write := func(object interface{}) error {
+		name := "doc-" + strconv.Itoa(index)
+		if namePath != nil {
+			if value, ok := lookupSegments(object, namePath); ok && value != nil {
+				name = explodeName(fmt.Sprint(value))
+			}
+		}
+		if used[name] {
+			suffix := index
+			for used[name+"-"+strconv.Itoa(suffix)] {
+				suffix++
+			}
+			name += "-" + strconv.Itoa(suffix)
+		}
+		used[name] = true
+		index++
+
+		document, err := render(object, to.marshal)
+		if err != nil || document == nil {
+			return err
+		}
+		output := filepath.Join(outputDir, name+outputSuffix)
+		logrus.Debugf("writing document %d to %v", index-1, output)
+		return writeOutput(output, document)
+	}
+
+	for _, path := range paths {
+		if inputTar {
+			objects, err := unmarshalTarEntries(path, from.unmarshal)
+			if err != nil {
+				return err
+			}
+			for _, object := range objects {
+				if err := write(object); err != nil {
+					return err
+				}
+			}
+			progress.fileDone()
+			continue
+		}
+		if from.stream == nil {
+			object, err := unmarshalPath(path, from.unmarshal)
+			if err != nil {
+				return err
+			}
+			if object == nil {
+				continue
+			}
+			if err := write(object); err != nil {
+				return err
+			}
+			continue
+		}
+		input, err := openInput(path)
+		if err != nil {
+			return err
+		}
+		input = progress.reader(path, input)
+		err = from.stream(input, func(object interface{}) error {
+			if err := checkDepth(path, object); err != nil {
+				return err
+			}
+			if err := validateSchema(path, object); err != nil {
+				return err
+			}
+			object, err := applyPatch(object)
+			if err != nil {
+				return err
+			}
+			return write(object)
+		})
+		input.Close()
+		if err != nil {
+			return fmt.Errorf("cannot convert %s: %v", inputName(path), err)
+		}
+		progress.fileDone()
+	}
+	if index == 0 {
+		return emptyResult()
+	}
+	return nil
+}
+
+// explodeName makes a file name of the --name-field value.
+func explodeName(value string) string {
+	name := strings.Map(func(c rune) rune {
+		if c == '/' || c == '\\' || c == os.PathSeparator || c < ' ' {
+			return '_'
+		}
+		return c
+	}, value)
+	if name == "" || name == "." || name == ".." {
+		return "_" + name
+	}
+	return name
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"strings"
+)
+
+var fingerprintAlgo string
+
+// fingerprintHashes are the --algo values of the fingerprint command.
+var fingerprintHashes = map[string]func() hash.Hash{
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+}
+
+// fingerprint writes the hash of the canonical JSON of every input, so that
+// the key order, the whitespace and even the format do not change it.
+// A single input writes the bare hash, several write a "hash  name" line each.
+func fingerprint() error {
+	newHash, ok := fingerprintHashes[fingerprintAlgo]
+	if !ok {
+		return usageError(fmt.Sprintf("unsupported --algo %q, expected 'sha1' or 'sha256'", fingerprintAlgo))
+	}
+	paths, err := expandInputs(inputPaths)
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		paths = []string{""}
+	}
+	var lines []string
+	for _, path := range paths {
+		from, err := formatForPath(path)
+		if err != nil {
+			return err
+		}
+		content, err := readInput(path)
+		if err != nil {
+			return err
+		}
+		object, err := from.unmarshal(content)
+		if err != nil {
+			return fmt.Errorf("cannot parse %s: %v", inputName(path), err)
+		}
+		// encoding/json writes the keys of the maps sorted and without whitespace
+		canonical, err := json.Marshal(object)
+		if err != nil {
+			return err
+		}
+		digest := newHash()
+		digest.Write(canonical)
+		sum := hex.EncodeToString(digest.Sum(nil))
+		if len(paths) == 1 {
+			lines = append(lines, sum)
+		} else {
+			lines = append(lines, sum+"  "+inputName(path))
+		}
+	}
+	return writeOutput(outputPath, []byte(strings.Join(lines, "\n")+"\n"))
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"github.com/urfave/cli"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fingerprintFiles writes the files into a temporary directory
+// and returns the output of fingerprint for them.
+func fingerprintFiles(t *testing.T, algo string, files map[string]string, names ...string) string {
+	dir := t.TempDir()
+	var paths []string
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(files[name]), 0644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+	defer func(inputs cli.StringSlice, output, algorithm string) {
+		inputPaths, outputPath, fingerprintAlgo = inputs, output, algorithm
+	}(inputPaths, outputPath, fingerprintAlgo)
+	inputPaths, outputPath, fingerprintAlgo = paths, filepath.Join(dir, "fingerprint"), algo
+	if err := fingerprint(); err != nil {
+		t.Fatal(err)
+	}
+	output, err := ioutil.ReadFile(outputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Replace(string(output), dir+string(filepath.Separator), "", -1)
+}
+
+func TestFingerprint(t *testing.T) {
+	files := map[string]string{
+		"a.json":      `{"a":1}`,
+		"spaced.json": "{\n  \"a\": 1\n}\n",
+		"a.yaml":      "a: 1\n",
+		"b.json":      `{"a":1,"b":[1,2]}`,
+		"b.yaml":      "b:\n- 1\n- 2\na: 1.0\n",
+		"c.json":      `{"a":2}`,
+	}
+	const sum = "015abd7f5cc57a2dd94b7590f04ad8084273905ee33ec5cebeae62276a97f862"
+	tests := []struct {
+		name     string
+		algo     string
+		inputs   []string
+		expected string
+	}{
+		{"sha256", "sha256", []string{"a.json"}, sum + "\n"},
+		{"whitespace", "sha256", []string{"spaced.json"}, sum + "\n"},
+		{"format", "sha256", []string{"a.yaml"}, sum + "\n"},
+		{"sha1", "sha1", []string{"a.json"}, "9f89c740ceb46d7418c924a78ac57941d5e96520\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output := fingerprintFiles(t, test.algo, files, test.inputs...)
+			if output != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, output)
+			}
+		})
+	}
+	if a, b := fingerprintFiles(t, "sha256", files, "b.json"), fingerprintFiles(t, "sha256", files, "b.yaml"); a != b {
+		t.Errorf("expected the key order not to matter, got %s and %s", a, b)
+	}
+	if a, c := fingerprintFiles(t, "sha256", files, "a.json"), fingerprintFiles(t, "sha256", files, "c.json"); a == c {
+		t.Errorf("expected different values to have different fingerprints, got %s", a)
+	}
+	lines := strings.Split(fingerprintFiles(t, "sha256", files, "a.json", "c.json"), "\n")
+	if len(lines) != 3 || lines[0] != sum+"  a.json" || !strings.HasSuffix(lines[1], "  c.json") {
+		t.Errorf("expected a hash and name line per input, got %q", lines)
+	}
+}
+
+func TestFingerprintUnsupportedAlgo(t *testing.T) {
+	defer func(algorithm string) { fingerprintAlgo = algorithm }(fingerprintAlgo)
+	fingerprintAlgo = "md5"
+	if err := fingerprint(); err == nil {
+		t.Error("expected an error for --algo md5")
+	}
+}
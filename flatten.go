@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+	"github.com/urfave/cli"
+	"strconv"
+	"strings"
+)
+
+var (
+	flattenSeparator string
+	unflatten        bool
+)
+
+var flattenFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:        "separator",
+		Usage:       "the separator of the nested keys",
+		Value:       ".",
+		Destination: &flattenSeparator,
+	},
+	cli.BoolFlag{
+		Name:        "unflatten",
+		Usage:       "expand the flat keys back into nested objects and arrays instead",
+		Destination: &unflatten,
+	},
+}
+
+// flattenFormat decodes the input like from, then flattens it,
+// or unflattens it with --unflatten.
+func flattenFormat(from format) format {
+	return format{
+		unmarshal: func(input []byte) (interface{}, error) {
+			object, err := from.unmarshal(input)
+			if err != nil || object == nil {
+				return object, err
+			}
+			if unflatten {
+				return unflattenObject(object)
+			}
+			flat := map[string]interface{}{}
+			flattenValue(object, "", flat)
+			return flat, nil
+		},
+	}
+}
+
+// flattenValue collects the leaves of the value under their joined keys,
+// array items being indexed like a.b[0]. The empty objects and arrays are
+// kept as leaves so that unflattening restores them.
+func flattenValue(value interface{}, key string, flat map[string]interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 && key != "" {
+			flat[key] = v
+		}
+		for name, item := range v {
+			child := name
+			if key != "" {
+				child = key + flattenSeparator + name
+			}
+			flattenValue(item, child, flat)
+		}
+	case []interface{}:
+		if len(v) == 0 && key != "" {
+			flat[key] = v
+		}
+		for i, item := range v {
+			flattenValue(item, fmt.Sprintf("%s[%d]", key, i), flat)
+		}
+	default:
+		flat[key] = v
+	}
+}
+
+// unflattenObject nests the values of the flat object under their keys.
+func unflattenObject(object interface{}) (interface{}, error) {
+	flat, ok := object.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("--unflatten requires an object of flat keys, not %s", jsonKind(object))
+	}
+	var root interface{}
+	for key, value := range flat {
+		segments, err := parseFlatKey(key)
+		if err != nil {
+			return nil, err
+		}
+		for _, segment := range segments {
+			// every item of a flattened array has a key of its own
+			if segment.index != nil && *segment.index >= len(flat) {
+				return nil, fmt.Errorf("the array index %d of the key %q is beyond the %d flat keys", *segment.index, key, len(flat))
+			}
+		}
+		if root, err = unflattenValue(root, segments, value, key); err != nil {
+			return nil, err
+		}
+	}
+	return root, nil
+}
+
+// parseFlatKey splits the key on the separator and the [N] array indexes.
+func parseFlatKey(key string) ([]selectSegment, error) {
+	var segments []selectSegment
+	for _, part := range strings.Split(key, flattenSeparator) {
+		name := part
+		if i := strings.Index(part, "["); i >= 0 && strings.HasSuffix(part, "]") {
+			name, part = part[:i], part[i:]
+		} else {
+			part = ""
+		}
+		if name != "" || part == "" {
+			segments = append(segments, selectSegment{key: name})
+		}
+		for part != "" {
+			end := strings.Index(part, "]")
+			if !strings.HasPrefix(part, "[") || end < 1 {
+				return nil, fmt.Errorf("malformed array index in the key %q", key)
+			}
+			index, err := strconv.Atoi(part[1:end])
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("malformed array index in the key %q", key)
+			}
+			segments = append(segments, selectSegment{index: &index})
+			part = part[end+1:]
+		}
+	}
+	return segments, nil
+}
+
+// unflattenValue sets the value under the segments of the node,
+// creating the missing objects and arrays on the way.
+func unflattenValue(node interface{}, segments []selectSegment, value interface{}, key string) (interface{}, error) {
+	if len(segments) == 0 {
+		if node != nil {
+			return nil, fmt.Errorf("the key %q conflicts with another key", key)
+		}
+		return value, nil
+	}
+	segment := segments[0]
+	if segment.index != nil {
+		if node == nil {
+			node = []interface{}{}
+		}
+		items, ok := node.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("the key %q conflicts with another key", key)
+		}
+		for len(items) <= *segment.index {
+			items = append(items, nil)
+		}
+		item, err := unflattenValue(items[*segment.index], segments[1:], value, key)
+		if err != nil {
+			return nil, err
+		}
+		items[*segment.index] = item
+		return items, nil
+	}
+	if node == nil {
+		node = map[string]interface{}{}
+	}
+	object, ok := node.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("the key %q conflicts with another key", key)
+	}
+	item, err := unflattenValue(object[segment.key], segments[1:], value, key)
+	if err != nil {
+		return nil, err
+	}
+	object[segment.key] = item
+	return object, nil
+}
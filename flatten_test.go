@@ -0,0 +1,120 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFlatten(t *testing.T) {
+	tests := []struct {
+		name      string
+		separator string
+		input     string
+		expected  interface{}
+	}{
+		{"flat", ".", `{"a":1}`, map[string]interface{}{"a": float64(1)}},
+		{"nested", ".", `{"a":{"b":{"c":1}},"d":"x"}`, map[string]interface{}{"a.b.c": float64(1), "d": "x"}},
+		{"arrays", ".", `{"a":{"b":[1,{"c":2}]}}`, map[string]interface{}{"a.b[0]": float64(1), "a.b[1].c": float64(2)}},
+		{"nested arrays", ".", `{"a":[[1]]}`, map[string]interface{}{"a[0][0]": float64(1)}},
+		{"top-level array", ".", `[1,2]`, map[string]interface{}{"[0]": float64(1), "[1]": float64(2)}},
+		{"empty values", ".", `{"a":{},"b":[],"c":null}`, map[string]interface{}{
+			"a": map[string]interface{}{}, "b": []interface{}{}, "c": nil,
+		}},
+		{"separator", "/", `{"a":{"b":1}}`, map[string]interface{}{"a/b": float64(1)}},
+	}
+	defer func(separator string) { flattenSeparator = separator }(flattenSeparator)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			flattenSeparator = test.separator
+			object, err := flattenFormat(jsonFormat).unmarshal([]byte(test.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(object, test.expected) {
+				t.Errorf("expected %#v, got %#v", test.expected, object)
+			}
+		})
+	}
+}
+
+func TestUnflatten(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected interface{}
+	}{
+		{"nested", `{"a.b.c":1,"d":"x"}`, map[string]interface{}{
+			"a": map[string]interface{}{"b": map[string]interface{}{"c": float64(1)}}, "d": "x",
+		}},
+		{"arrays", `{"a.b[0]":1,"a.b[1].c":2}`, map[string]interface{}{
+			"a": map[string]interface{}{"b": []interface{}{float64(1), map[string]interface{}{"c": float64(2)}}},
+		}},
+		{"sparse array", `{"a[1]":1,"b":2}`, map[string]interface{}{"a": []interface{}{nil, float64(1)}, "b": float64(2)}},
+		{"top-level array", `{"[0]":1,"[1]":2}`, []interface{}{float64(1), float64(2)}},
+	}
+	defer func(separator string, enabled bool) {
+		flattenSeparator, unflatten = separator, enabled
+	}(flattenSeparator, unflatten)
+	flattenSeparator, unflatten = ".", true
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			object, err := flattenFormat(jsonFormat).unmarshal([]byte(test.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(object, test.expected) {
+				t.Errorf("expected %#v, got %#v", test.expected, object)
+			}
+		})
+	}
+}
+
+func TestUnflattenErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"not an object", `[1]`},
+		{"conflicting keys", `{"a":1,"a.b":2}`},
+		{"object and array", `{"a[0]":1,"a.b":2}`},
+		{"unbalanced brackets", `{"a[0]]":1}`},
+		{"negative index", `{"a[-1]":1}`},
+		{"non-numeric index", `{"a[x]":1}`},
+		{"huge index", `{"a[999999999999]":1}`},
+		{"index beyond the keys", `{"a[5]":1}`},
+	}
+	defer func(separator string, enabled bool) {
+		flattenSeparator, unflatten = separator, enabled
+	}(flattenSeparator, unflatten)
+	flattenSeparator, unflatten = ".", true
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := flattenFormat(jsonFormat).unmarshal([]byte(test.input)); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestFlattenRoundTrip(t *testing.T) {
+	input := `{"a":{"b":[1,{"c":[true,null]}],"d":{}},"e":[],"f":"x"}`
+	defer func(separator string, enabled bool) {
+		flattenSeparator, unflatten = separator, enabled
+	}(flattenSeparator, unflatten)
+	flattenSeparator, unflatten = ".", false
+	flat, err := flattenFormat(jsonFormat).unmarshal([]byte(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	object, err := unflattenObject(flat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := unmarshalJSON([]byte(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(object, expected) {
+		t.Errorf("expected %#v, got %#v", expected, object)
+	}
+}
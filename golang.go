@@ -0,0 +1,306 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	goformat "go/format"
+	"go/token"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var goTypeName string
+
+var goFormat = format{marshal: marshalGo, separator: "\n"}
+
+// goInitialisms are written in upper case in the field names, like golint wants.
+var goInitialisms = map[string]bool{
+	"API": true, "CPU": true, "DNS": true, "HTML": true, "HTTP": true, "HTTPS": true, "ID": true,
+	"IP": true, "JSON": true, "SQL": true, "TCP": true, "TLS": true, "UDP": true, "UID": true,
+	"URI": true, "URL": true, "UUID": true, "XML": true, "YAML": true,
+}
+
+// goType is the Go type inferred for a JSON value. The elements of an array
+// are unified into a single type, their objects into a struct of all of
+// their fields, and values of different kinds into interface{}.
+type goType struct {
+	// kind is struct, slice, string, int, float64, bool, null or interface
+	kind   string
+	name   string
+	fields []*goField
+	elem   *goType
+}
+
+type goField struct {
+	key  string
+	name string
+	typ  *goType
+}
+
+// marshalGo writes the struct types of the object, named after the --type-name,
+// and a variable holding the object as a Go literal of these types.
+func marshalGo(object interface{}) ([]byte, error) {
+	if !token.IsIdentifier(goTypeName) {
+		return nil, usageError(fmt.Sprintf("invalid --type-name %q, expected a Go identifier", goTypeName))
+	}
+	typ := inferGoType(object)
+	var structs []*goType
+	nameGoTypes(typ, goTypeName, map[string]bool{}, &structs)
+
+	var output bytes.Buffer
+	output.WriteString("package main\n\n")
+	for _, s := range structs {
+		fmt.Fprintf(&output, "type %s struct {\n", s.name)
+		for _, field := range s.fields {
+			fmt.Fprintf(&output, "%s %s `json:%q`\n", field.name, goTypeExpr(field.typ), field.key)
+		}
+		output.WriteString("}\n\n")
+	}
+	fmt.Fprintf(&output, "var %s = ", goVariableName(goTypeName))
+	writeGoLiteral(&output, object, typ)
+	output.WriteString("\n")
+
+	formatted, err := goformat.Source(output.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("cannot format the generated Go code: %v", err)
+	}
+	// the package clause is only there for go/format
+	return bytes.TrimPrefix(formatted, []byte("package main\n\n")), nil
+}
+
+func inferGoType(value interface{}) *goType {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		typ := &goType{kind: "struct"}
+		for _, key := range orderedKeys(v) {
+			typ.fields = append(typ.fields, &goField{key: key, typ: inferGoType(v[key])})
+		}
+		return typ
+	case []interface{}:
+		elem := &goType{kind: "null"}
+		for _, item := range v {
+			elem = unifyGoTypes(elem, inferGoType(item))
+		}
+		return &goType{kind: "slice", elem: elem}
+	case string:
+		return &goType{kind: "string"}
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+			return &goType{kind: "int"}
+		}
+		return &goType{kind: "float64"}
+	case int:
+		return &goType{kind: "int"}
+	case bool:
+		return &goType{kind: "bool"}
+	case nil:
+		return &goType{kind: "null"}
+	}
+	return &goType{kind: "interface"}
+}
+
+func unifyGoTypes(a, b *goType) *goType {
+	switch {
+	case a.kind == "null":
+		return b
+	case b.kind == "null":
+		return a
+	case a.kind == "int" && b.kind == "float64", a.kind == "float64" && b.kind == "int":
+		return &goType{kind: "float64"}
+	case a.kind != b.kind:
+		return &goType{kind: "interface"}
+	case a.kind == "slice":
+		return &goType{kind: "slice", elem: unifyGoTypes(a.elem, b.elem)}
+	case a.kind == "struct":
+		merged := &goType{kind: "struct"}
+		fields := map[string]*goField{}
+		for _, field := range append(append([]*goField{}, a.fields...), b.fields...) {
+			if existing, ok := fields[field.key]; ok {
+				existing.typ = unifyGoTypes(existing.typ, field.typ)
+				continue
+			}
+			copied := &goField{key: field.key, typ: field.typ}
+			fields[field.key] = copied
+			merged.fields = append(merged.fields, copied)
+		}
+		return merged
+	}
+	return a
+}
+
+// nameGoTypes names the structs after the path of fields leading to them,
+// and the fields after their keys, collecting the structs in order.
+func nameGoTypes(typ *goType, name string, used map[string]bool, structs *[]*goType) {
+	switch typ.kind {
+	case "slice":
+		nameGoTypes(typ.elem, name, used, structs)
+	case "struct":
+		typ.name = uniqueGoName(name, used)
+		*structs = append(*structs, typ)
+		fields := map[string]bool{}
+		for _, field := range typ.fields {
+			field.name = uniqueGoName(goFieldName(field.key), fields)
+		}
+		for _, field := range typ.fields {
+			nameGoTypes(field.typ, typ.name+field.name, used, structs)
+		}
+	}
+}
+
+func uniqueGoName(name string, used map[string]bool) string {
+	unique := name
+	for i := 2; used[unique]; i++ {
+		unique = name + strconv.Itoa(i)
+	}
+	used[unique] = true
+	return unique
+}
+
+// goFieldName camel-cases the key into an exported identifier, splitting it
+// on the characters that cannot be in an identifier and on the case changes.
+func goFieldName(key string) string {
+	var words []string
+	var word []rune
+	previous := rune(0)
+	for _, c := range key {
+		if !unicode.IsLetter(c) && !unicode.IsDigit(c) {
+			if len(word) > 0 {
+				words = append(words, string(word))
+			}
+			word, previous = nil, 0
+			continue
+		}
+		if len(word) > 0 && unicode.IsUpper(c) && unicode.IsLower(previous) {
+			words = append(words, string(word))
+			word = nil
+		}
+		word = append(word, c)
+		previous = c
+	}
+	if len(word) > 0 {
+		words = append(words, string(word))
+	}
+
+	var name strings.Builder
+	for _, word := range words {
+		if upper := strings.ToUpper(word); goInitialisms[upper] {
+			name.WriteString(upper)
+			continue
+		}
+		runes := []rune(word)
+		name.WriteRune(unicode.ToUpper(runes[0]))
+		name.WriteString(string(runes[1:]))
+	}
+	if name.Len() == 0 {
+		return "Field"
+	}
+	if first := []rune(name.String())[0]; unicode.IsDigit(first) {
+		return "X" + name.String()
+	}
+	return name.String()
+}
+
+// goVariableName is the type name starting in lower case, with a Value suffix
+// when that is a keyword, like type, or the type name itself.
+func goVariableName(typeName string) string {
+	runes := []rune(typeName)
+	runes[0] = unicode.ToLower(runes[0])
+	name := string(runes)
+	if token.IsKeyword(name) || name == typeName {
+		return name + "Value"
+	}
+	return name
+}
+
+func goTypeExpr(typ *goType) string {
+	switch typ.kind {
+	case "struct":
+		return typ.name
+	case "slice":
+		return "[]" + goTypeExpr(typ.elem)
+	case "null", "interface":
+		return "interface{}"
+	}
+	return typ.kind
+}
+
+func writeGoLiteral(output *bytes.Buffer, value interface{}, typ *goType) {
+	switch typ.kind {
+	case "struct":
+		object := value.(map[string]interface{})
+		fmt.Fprintf(output, "%s{\n", typ.name)
+		for _, field := range typ.fields {
+			item, ok := object[field.key]
+			if !ok || item == nil {
+				continue
+			}
+			fmt.Fprintf(output, "%s: ", field.name)
+			writeGoLiteral(output, item, field.typ)
+			output.WriteString(",\n")
+		}
+		output.WriteString("}")
+	case "slice":
+		fmt.Fprintf(output, "%s{\n", goTypeExpr(typ))
+		for _, item := range value.([]interface{}) {
+			if item == nil {
+				output.WriteString(goZero(typ.elem))
+			} else {
+				writeGoLiteral(output, item, typ.elem)
+			}
+			output.WriteString(",\n")
+		}
+		output.WriteString("}")
+	default:
+		writeGoInterface(output, value)
+	}
+}
+
+// goZero is the literal of the zero value of the type, for the null elements of an array.
+func goZero(typ *goType) string {
+	switch typ.kind {
+	case "struct":
+		return "{}"
+	case "string":
+		return `""`
+	case "int", "float64":
+		return "0"
+	case "bool":
+		return "false"
+	}
+	return "nil"
+}
+
+// writeGoInterface writes the value as the literal of an interface{}.
+func writeGoInterface(output *bytes.Buffer, value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		output.WriteString("map[string]interface{}{\n")
+		for _, key := range orderedKeys(v) {
+			fmt.Fprintf(output, "%q: ", key)
+			writeGoInterface(output, v[key])
+			output.WriteString(",\n")
+		}
+		output.WriteString("}")
+	case []interface{}:
+		output.WriteString("[]interface{}{\n")
+		for _, item := range v {
+			writeGoInterface(output, item)
+			output.WriteString(",\n")
+		}
+		output.WriteString("}")
+	case string:
+		output.WriteString(strconv.Quote(v))
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+			output.WriteString(strconv.FormatInt(int64(v), 10))
+		} else {
+			output.WriteString(strconv.FormatFloat(v, 'g', -1, 64))
+		}
+	case nil:
+		output.WriteString("nil")
+	default:
+		fmt.Fprint(output, v)
+	}
+}
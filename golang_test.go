@@ -0,0 +1,115 @@
+package main
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+)
+
+func TestGoFieldName(t *testing.T) {
+	tests := []struct {
+		key      string
+		expected string
+	}{
+		{"name", "Name"},
+		{"user_name", "UserName"},
+		{"user-name", "UserName"},
+		{"userName", "UserName"},
+		{"id", "ID"},
+		{"api_url", "APIURL"},
+		{"https", "HTTPS"},
+		{"1st", "X1st"},
+		{"", "Field"},
+		{"---", "Field"},
+		{"ünïcode", "Ünïcode"},
+	}
+	for _, test := range tests {
+		t.Run(test.key, func(t *testing.T) {
+			if name := goFieldName(test.key); name != test.expected {
+				t.Errorf("expected %s, got %s", test.expected, name)
+			}
+		})
+	}
+}
+
+func TestGoVariableName(t *testing.T) {
+	tests := []struct {
+		typeName string
+		expected string
+	}{
+		{"Config", "config"},
+		{"HTTPServer", "hTTPServer"},
+		{"Type", "typeValue"},
+		{"config", "configValue"},
+	}
+	for _, test := range tests {
+		t.Run(test.typeName, func(t *testing.T) {
+			if name := goVariableName(test.typeName); name != test.expected {
+				t.Errorf("expected %s, got %s", test.expected, name)
+			}
+		})
+	}
+}
+
+func TestMarshalGo(t *testing.T) {
+	tests := []struct {
+		name     string
+		typeName string
+		input    string
+		expected []string
+	}{
+		{"scalars", "Config", `{"name":"a","port":80,"ratio":0.5,"on":true,"none":null}`, []string{
+			"type Config struct {\n\tName  string      `json:\"name\"`\n\tNone  interface{} `json:\"none\"`\n\tOn    bool        `json:\"on\"`\n\tPort  int         `json:\"port\"`\n\tRatio float64     `json:\"ratio\"`\n}",
+			"var config = Config{\n\tName:  \"a\",\n\tOn:    true,\n\tPort:  80,\n\tRatio: 0.5,\n}",
+		}},
+		{"nested struct", "Config", `{"server":{"host":"a"}}`, []string{
+			"Server ConfigServer `json:\"server\"`",
+			"type ConfigServer struct {\n\tHost string `json:\"host\"`\n}",
+		}},
+		{"unified elements", "Config", `{"items":[{"a":1},{"a":2.5,"b":"x"}]}`, []string{
+			"Items []ConfigItems `json:\"items\"`",
+			"type ConfigItems struct {\n\tA float64 `json:\"a\"`\n\tB string  `json:\"b\"`\n}",
+		}},
+		{"mixed elements", "Config", `{"mixed":[1,"a"],"empty":[]}`, []string{
+			"Empty []interface{} `json:\"empty\"`",
+			"Mixed []interface{} `json:\"mixed\"`",
+		}},
+		{"colliding field names", "Config", `{"user_name":"a","userName":"b"}`, []string{
+			"UserName  string `json:\"userName\"`",
+			"UserName2 string `json:\"user_name\"`",
+		}},
+		{"escaped strings", "Config", `{"text":"say \"hi\"\n"}`, []string{`Text: "say \"hi\"\n",`}},
+		{"top-level array", "Item", `[{"a":1}]`, []string{"var item = []Item{\n\tItem{\n\t\tA: 1,\n\t},\n}"}},
+		{"top-level scalar", "Item", `"x"`, []string{`var item = "x"`}},
+		{"keyword type name", "Type", `{"a":1}`, []string{"var typeValue = Type{"}},
+	}
+	defer func(name string) { goTypeName = name }(goTypeName)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			goTypeName = test.typeName
+			output, err := convertText(jsonFormat, goFormat, test.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, expected := range test.expected {
+				if !strings.Contains(output, expected) {
+					t.Errorf("expected %q in\n%s", expected, output)
+				}
+			}
+			if _, err := parser.ParseFile(token.NewFileSet(), "", "package main\n\n"+output, 0); err != nil {
+				t.Errorf("expected valid Go code, got %v in\n%s", err, output)
+			}
+		})
+	}
+}
+
+func TestMarshalGoInvalidTypeName(t *testing.T) {
+	defer func(name string) { goTypeName = name }(goTypeName)
+	for _, name := range []string{"", "1Config", "my-config", "type"} {
+		goTypeName = name
+		if _, err := convertText(jsonFormat, goFormat, `{"a":1}`); err == nil {
+			t.Errorf("expected an error for --type-name %q", name)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"github.com/sirupsen/logrus"
+	"io"
+	"strings"
+)
+
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// gzipReadCloser closes both the decompressor and the underlying input.
+type gzipReadCloser struct {
+	*gzip.Reader
+	input io.Closer
+}
+
+func (r gzipReadCloser) Close() error {
+	err := r.Reader.Close()
+	if closeErr := r.input.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// decompressInput transparently decompresses the input when it starts with
+// the gzip magic bytes, and returns it unchanged otherwise.
+func decompressInput(input io.ReadCloser) (io.ReadCloser, error) {
+	buffered := bufio.NewReader(input)
+	magic, _ := buffered.Peek(len(gzipMagic))
+	if string(magic) != string(gzipMagic) {
+		return struct {
+			io.Reader
+			io.Closer
+		}{buffered, input}, nil
+	}
+	logrus.Debug("gzip compressed input, decompressing")
+	reader, err := gzip.NewReader(buffered)
+	if err != nil {
+		input.Close()
+		return nil, err
+	}
+	return gzipReadCloser{reader, input}, nil
+}
+
+// compressOutput makes write gzip its output when the output path ends in .gz.
+func compressOutput(path string, write func(io.Writer) error) func(io.Writer) error {
+	if !strings.HasSuffix(path, ".gz") {
+		return write
+	}
+	return func(w io.Writer) error {
+		logrus.Debug("output path ends in .gz, compressing")
+		compressed := gzip.NewWriter(w)
+		err := write(compressed)
+		if closeErr := compressed.Close(); err == nil {
+			err = closeErr
+		}
+		return err
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"testing"
+)
+
+func gzipped(t *testing.T, content string) string {
+	var output bytes.Buffer
+	writer := gzip.NewWriter(&output)
+	if _, err := writer.Write([]byte(content)); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return output.String()
+}
+
+func TestDecompressInput(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"plain", "a: 1\n", "a: 1\n"},
+		{"empty", "", ""},
+		{"one byte", "\x1f", "\x1f"},
+		{"gzip", gzipped(t, "a: 1\n"), "a: 1\n"},
+		{"empty gzip", gzipped(t, ""), ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			input, err := decompressInput(ioutil.NopCloser(bytes.NewReader([]byte(test.input))))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer input.Close()
+			output, err := ioutil.ReadAll(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(output) != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, output)
+			}
+		})
+	}
+}
+
+func TestDecompressInputCorrupted(t *testing.T) {
+	_, err := decompressInput(ioutil.NopCloser(bytes.NewReader([]byte("\x1f\x8bnot gzip"))))
+	if err == nil {
+		t.Error("expected an error for the corrupted gzip header")
+	}
+}
+
+func TestCompressOutput(t *testing.T) {
+	tests := []struct {
+		path       string
+		compressed bool
+	}{
+		{"", false},
+		{"output.yaml", false},
+		{"output.gz.yaml", false},
+		{"output.yaml.gz", true},
+		{"output.gz", true},
+	}
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			var output bytes.Buffer
+			write := compressOutput(test.path, func(w io.Writer) error {
+				_, err := w.Write([]byte("a: 1\n"))
+				return err
+			})
+			if err := write(&output); err != nil {
+				t.Fatal(err)
+			}
+			if compressed := bytes.HasPrefix(output.Bytes(), gzipMagic); compressed != test.compressed {
+				t.Fatalf("expected compressed %v, got %q", test.compressed, output.Bytes())
+			}
+			input, err := decompressInput(ioutil.NopCloser(&output))
+			if err != nil {
+				t.Fatal(err)
+			}
+			content, err := ioutil.ReadAll(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(content) != "a: 1\n" {
+				t.Errorf("expected %q, got %q", "a: 1\n", content)
+			}
+		})
+	}
+}
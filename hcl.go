@@ -0,0 +1,273 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+	"reflect"
+	"strings"
+)
+
+var hclFormat = format{unmarshal: unmarshalHCL, marshal: marshalHCL, separator: "\n"}
+
+// unmarshalHCL decodes HCL into its JSON representation: the blocks become
+// objects nested by type and labels, and repeated blocks become arrays.
+// Expressions that cannot be evaluated without a context, such as references
+// to variables, are kept as "${...}" interpolation strings.
+func unmarshalHCL(input []byte) (interface{}, error) {
+	file, diags := hclsyntax.ParseConfig(input, "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	body := file.Body.(*hclsyntax.Body)
+	if len(body.Attributes) == 0 && len(body.Blocks) == 0 {
+		return nil, nil
+	}
+	return hclBody(body, input)
+}
+
+func hclBody(body *hclsyntax.Body, source []byte) (map[string]interface{}, error) {
+	object := map[string]interface{}{}
+	for name, attribute := range body.Attributes {
+		value, err := hclExpression(attribute.Expr, source)
+		if err != nil {
+			return nil, err
+		}
+		object[name] = value
+	}
+	for _, block := range body.Blocks {
+		content, err := hclBody(block.Body, source)
+		if err != nil {
+			return nil, err
+		}
+		keys := append([]string{block.Type}, block.Labels...)
+		parent := object
+		for _, key := range keys[:len(keys)-1] {
+			child, ok := parent[key].(map[string]interface{})
+			if !ok {
+				child = map[string]interface{}{}
+				parent[key] = child
+			}
+			parent = child
+		}
+		name := keys[len(keys)-1]
+		if existing, ok := parent[name]; !ok {
+			parent[name] = content
+		} else if blocks, ok := existing.([]interface{}); ok {
+			parent[name] = append(blocks, content)
+		} else {
+			parent[name] = []interface{}{existing, content}
+		}
+	}
+	return object, nil
+}
+
+func hclExpression(expression hclsyntax.Expression, source []byte) (interface{}, error) {
+	value, diags := expression.Value(nil)
+	if diags.HasErrors() {
+		switch e := expression.(type) {
+		case *hclsyntax.ObjectConsExpr:
+			object := map[string]interface{}{}
+			for _, item := range e.Items {
+				key := hcl.ExprAsKeyword(item.KeyExpr)
+				if key == "" {
+					keyValue, err := hclExpression(item.KeyExpr, source)
+					if err != nil {
+						return nil, err
+					}
+					key = fmt.Sprint(keyValue)
+				}
+				value, err := hclExpression(item.ValueExpr, source)
+				if err != nil {
+					return nil, err
+				}
+				object[key] = value
+			}
+			return object, nil
+		case *hclsyntax.TupleConsExpr:
+			items := make([]interface{}, len(e.Exprs))
+			for i, item := range e.Exprs {
+				value, err := hclExpression(item, source)
+				if err != nil {
+					return nil, err
+				}
+				items[i] = value
+			}
+			return items, nil
+		}
+		text := string(expression.Range().SliceBytes(source))
+		if _, ok := expression.(*hclsyntax.TemplateExpr); ok && len(text) >= 2 {
+			return text[1 : len(text)-1], nil
+		}
+		return "${" + text + "}", nil
+	}
+	encoded, err := ctyjson.Marshal(value, value.Type())
+	if err != nil {
+		return nil, err
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		return nil, err
+	}
+	return decoded, nil
+}
+
+// marshalHCL writes the object as HCL, the reverse of unmarshalHCL:
+//   - an object becomes a block, and when all of its values are objects,
+//     its keys become the labels of the blocks nested in them, so that
+//     {"resource": {"aws_instance": {"web": {...}}}} is written as
+//     resource "aws_instance" "web" {...}
+//   - an array of objects becomes a repeated block
+//   - any other value becomes an attribute, the objects nested in arrays
+//     becoming object expressions
+//   - a "${...}" string becomes the expression it interpolates when that is
+//     a valid expression, and any other string is escaped, its ${ included
+//   - the attribute names and block types must be valid identifiers
+func marshalHCL(object interface{}) ([]byte, error) {
+	body, ok := object.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("HCL output requires a top-level object")
+	}
+	file := hclwrite.NewEmptyFile()
+	if err := writeHCLBody(file.Body(), body, true); err != nil {
+		return nil, err
+	}
+	return hclwrite.Format(file.Bytes()), nil
+}
+
+// writeHCLBody writes the attributes of the object, then its blocks,
+// separating the top-level blocks with an empty line.
+func writeHCLBody(body *hclwrite.Body, object map[string]interface{}, topLevel bool) error {
+	var blocks []string
+	for _, key := range orderedKeys(object) {
+		if !hclsyntax.ValidIdentifier(key) {
+			return fmt.Errorf("cannot write the key %q in HCL, the attribute names and block types must be identifiers", key)
+		}
+		if isHCLBlock(object[key]) {
+			blocks = append(blocks, key)
+			continue
+		}
+		body.SetAttributeRaw(key, hclTokens(object[key]))
+	}
+	for i, key := range blocks {
+		if topLevel && (i > 0 || len(blocks) < len(object)) {
+			body.AppendNewline()
+		}
+		items, ok := object[key].([]interface{})
+		if !ok {
+			items = []interface{}{object[key]}
+		}
+		for _, item := range items {
+			if err := writeHCLBlock(body, key, nil, item.(map[string]interface{})); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func writeHCLBlock(body *hclwrite.Body, blockType string, labels []string, object map[string]interface{}) error {
+	if len(object) > 0 && allHCLObjects(object) {
+		for _, key := range orderedKeys(object) {
+			if err := writeHCLBlock(body, blockType, append(labels[:len(labels):len(labels)], key), object[key].(map[string]interface{})); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	block := body.AppendNewBlock(blockType, labels)
+	return writeHCLBody(block.Body(), object, false)
+}
+
+// isHCLBlock reports whether the value is written as a block: an object
+// or a non-empty array of objects.
+func isHCLBlock(value interface{}) bool {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return true
+	case []interface{}:
+		if len(v) == 0 {
+			return false
+		}
+		for _, item := range v {
+			if _, ok := item.(map[string]interface{}); !ok {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func allHCLObjects(object map[string]interface{}) bool {
+	for _, value := range object {
+		if _, ok := value.(map[string]interface{}); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// hclTokens returns the expression of an attribute value.
+func hclTokens(value interface{}) hclwrite.Tokens {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		var attributes []hclwrite.ObjectAttrTokens
+		for _, key := range orderedKeys(v) {
+			name := hclwrite.TokensForValue(cty.StringVal(key))
+			if hclsyntax.ValidIdentifier(key) {
+				name = hclwrite.TokensForIdentifier(key)
+			}
+			attributes = append(attributes, hclwrite.ObjectAttrTokens{Name: name, Value: hclTokens(v[key])})
+		}
+		return hclwrite.TokensForObject(attributes)
+	case []interface{}:
+		items := make([]hclwrite.Tokens, len(v))
+		for i, item := range v {
+			items[i] = hclTokens(item)
+		}
+		return hclwrite.TokensForTuple(items)
+	case string:
+		if strings.HasPrefix(v, "${") && strings.HasSuffix(v, "}") && strings.Count(v, "${") == 1 {
+			if source := v[2 : len(v)-1]; isHCLExpression(source) {
+				return hclRawTokens(source)
+			}
+		}
+		return hclwrite.TokensForValue(cty.StringVal(v))
+	case float64:
+		return hclwrite.TokensForValue(cty.NumberFloatVal(v))
+	case int:
+		return hclwrite.TokensForValue(cty.NumberIntVal(int64(v)))
+	case bool:
+		return hclwrite.TokensForValue(cty.BoolVal(v))
+	case nil:
+		return hclwrite.TokensForValue(cty.NullVal(cty.DynamicPseudoType))
+	}
+	// the integers and floats of the other decoders, e.g. an int64 of TOML
+	switch reflected := reflect.ValueOf(value); reflected.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return hclwrite.TokensForValue(cty.NumberIntVal(reflected.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return hclwrite.TokensForValue(cty.NumberUIntVal(reflected.Uint()))
+	case reflect.Float32:
+		return hclwrite.TokensForValue(cty.NumberFloatVal(reflected.Float()))
+	}
+	return hclwrite.TokensForValue(cty.StringVal(fmt.Sprint(value)))
+}
+
+// isHCLExpression reports whether the source is a single valid expression.
+func isHCLExpression(source string) bool {
+	_, diags := hclsyntax.ParseExpression([]byte(source), "", hcl.InitialPos)
+	return strings.TrimSpace(source) != "" && !diags.HasErrors()
+}
+
+// hclRawTokens keeps the source of a valid expression as it is,
+// hclwrite.Format lexing it again into its tokens.
+func hclRawTokens(source string) hclwrite.Tokens {
+	return hclwrite.Tokens{{Type: hclsyntax.TokenIdent, Bytes: []byte(source)}}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUnmarshalHCL(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected interface{}
+	}{
+		{"empty", "", nil},
+		{"attributes", "name = \"web\"\ncount = 2\nenabled = true\n", map[string]interface{}{
+			"name": "web", "count": float64(2), "enabled": true,
+		}},
+		{"collections", "ports = [80, 443]\ntags = { env = \"prod\" }\n", map[string]interface{}{
+			"ports": []interface{}{float64(80), float64(443)},
+			"tags":  map[string]interface{}{"env": "prod"},
+		}},
+		{"block", "terraform {\n  required_version = \">= 1.0\"\n}\n", map[string]interface{}{
+			"terraform": map[string]interface{}{"required_version": ">= 1.0"},
+		}},
+		{"labeled block", "resource \"aws_instance\" \"web\" {\n  ami = \"abc\"\n}\n", map[string]interface{}{
+			"resource": map[string]interface{}{
+				"aws_instance": map[string]interface{}{
+					"web": map[string]interface{}{"ami": "abc"},
+				},
+			},
+		}},
+		{"repeated blocks", "ingress {\n  port = 80\n}\ningress {\n  port = 443\n}\n", map[string]interface{}{
+			"ingress": []interface{}{
+				map[string]interface{}{"port": float64(80)},
+				map[string]interface{}{"port": float64(443)},
+			},
+		}},
+		{"references", "ami = var.ami\nname = \"${var.prefix}-web\"\nlist = [var.a, 1]\n", map[string]interface{}{
+			"ami":  "${var.ami}",
+			"name": "${var.prefix}-web",
+			"list": []interface{}{"${var.a}", float64(1)},
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			object, err := unmarshalHCL([]byte(test.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(object, test.expected) {
+				t.Errorf("expected %#v, got %#v", test.expected, object)
+			}
+		})
+	}
+}
+
+func TestUnmarshalHCLError(t *testing.T) {
+	if _, err := unmarshalHCL([]byte("name = \n")); err == nil {
+		t.Error("expected an error for the missing value")
+	}
+}
+
+func TestMarshalHCL(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"attributes", `{"name":"web","count":2,"enabled":true,"none":null}`, "count   = 2\nenabled = true\nname    = \"web\"\nnone    = null\n"},
+		{"block", `{"terraform":{"required_version":">= 1.0"}}`, "terraform {\n  required_version = \">= 1.0\"\n}\n"},
+		{"labeled block", `{"resource":{"aws_instance":{"web":{"ami":"abc"}}}}`, "resource \"aws_instance\" \"web\" {\n  ami = \"abc\"\n}\n"},
+		{"repeated blocks", `{"ingress":[{"port":80},{"port":443}]}`, "ingress {\n  port = 80\n}\ningress {\n  port = 443\n}\n"},
+		{"separated blocks", `{"a":{"x":1},"b":{"y":2},"v":1}`, "v = 1\n\na {\n  x = 1\n}\n\nb {\n  y = 2\n}\n"},
+		{"tuples", `{"ports":[80,443],"empty":[]}`, "empty = []\nports = [80, 443]\n"},
+		{"objects in tuples", `{"list":[1,{"a b":2}]}`, "list = [1, {\n  \"a b\" = 2\n}]\n"},
+		{"escaped strings", `{"text":"say \"hi\"\n"}`, "text = \"say \\\"hi\\\"\\n\"\n"},
+		{"escaped templates", `{"text":"${a} and %{b}"}`, "text = \"$${a} and %%{b}\"\n"},
+		{"interpolated expression", `{"ami":"${var.ami}"}`, "ami = var.ami\n"},
+		{"invalid expression", `{"ami":"${var.}"}`, "ami = \"$${var.}\"\n"},
+		{"interpolations", `{"name":"${a}-${b}"}`, "name = \"$${a}-$${b}\"\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output, err := convertText(jsonFormat, hclFormat, test.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if output != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, output)
+			}
+		})
+	}
+}
+
+func TestMarshalHCLErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"top-level array", `[1]`},
+		{"top-level string", `"a"`},
+		{"attribute name", `{"a b":1}`},
+		{"block type", `{"a-b c":{"x":1}}`},
+		{"attribute name in a block", `{"a":{"1x":1}}`},
+		{"empty attribute name", `{"":1}`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := convertText(jsonFormat, hclFormat, test.input); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestHCLRoundTrip(t *testing.T) {
+	input := `{"resource":{"aws_instance":{"web":{"ami":"${var.ami}","count":2,"name":"a ${b} %{c}"}}},"tags":{"env":"prod"}}`
+	output, err := convertText(jsonFormat, hclFormat, input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	object, err := unmarshalHCL([]byte(output))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := unmarshalJSON([]byte(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(object, expected) {
+		t.Errorf("expected %#v, got %#v from\n%s", expected, object, output)
+	}
+}
+
+func TestMarshalHCLIntegers(t *testing.T) {
+	tests := []struct {
+		name     string
+		preserve bool
+		from     format
+		input    string
+		expected string
+	}{
+		{"toml", false, tomlFormat, "a = 1\nports = [80, 443]\n", "a     = 1\nports = [80, 443]\n"},
+		{"preserved toml", true, tomlFormat, "a = 9007199254740993\n", "a = 9007199254740993\n"},
+		{"preserved json", true, jsonFormat, `{"a":9007199254740993,"b":{"c":-2}}`, "a = 9007199254740993\n\nb {\n  c = -2\n}\n"},
+	}
+	defer func(preserve bool) { preserveInt = preserve }(preserveInt)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			preserveInt = test.preserve
+			output, err := convertText(test.from, hclFormat, test.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if output != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, output)
+			}
+		})
+	}
+}
+
+func TestHCLTokensNumbers(t *testing.T) {
+	tests := []struct {
+		value    interface{}
+		expected string
+	}{
+		{int64(-3), "-3"},
+		{uint64(18446744073709551615), "18446744073709551615"},
+		{int8(7), "7"},
+		{float32(0.5), "0.5"},
+	}
+	for _, test := range tests {
+		t.Run(test.expected, func(t *testing.T) {
+			if output := string(hclTokens(test.value).Bytes()); output != test.expected {
+				t.Errorf("expected %s, got %s", test.expected, output)
+			}
+		})
+	}
+}
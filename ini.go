@@ -0,0 +1,31 @@
+package main
+
+import "gopkg.in/ini.v1"
+
+var iniFormat = format{unmarshal: unmarshalINI, stringValues: true}
+
+// unmarshalINI decodes the input into a map of sections to their keys and
+// values, the keys outside of any section are stored under "DEFAULT".
+// When a key is repeated within a section the last value wins.
+func unmarshalINI(input []byte) (interface{}, error) {
+	file, err := ini.Load(input)
+	if err != nil {
+		return nil, err
+	}
+	object := map[string]interface{}{}
+	for _, section := range file.Sections() {
+		keys := section.Keys()
+		if len(keys) == 0 && section.Name() == ini.DefaultSection {
+			continue
+		}
+		values := map[string]interface{}{}
+		for _, key := range keys {
+			values[key.Name()] = key.Value()
+		}
+		object[section.Name()] = values
+	}
+	if len(object) == 0 {
+		return nil, nil
+	}
+	return object, nil
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUnmarshalINI(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected interface{}
+	}{
+		{"empty", "", nil},
+		{"comments only", "; nothing\n# here\n", nil},
+		{"default section", "name = 2fy\n", map[string]interface{}{
+			"DEFAULT": map[string]interface{}{"name": "2fy"},
+		}},
+		{"sections", "name = 2fy\n[server]\nhost = localhost\nport = 80\n", map[string]interface{}{
+			"DEFAULT": map[string]interface{}{"name": "2fy"},
+			"server":  map[string]interface{}{"host": "localhost", "port": "80"},
+		}},
+		{"empty section", "[server]\n", map[string]interface{}{
+			"server": map[string]interface{}{},
+		}},
+		{"repeated key", "[server]\nport = 80\nport = 443\n", map[string]interface{}{
+			"server": map[string]interface{}{"port": "443"},
+		}},
+		{"quoted value", "[server]\nmotd = \"hello world\"\n", map[string]interface{}{
+			"server": map[string]interface{}{"motd": "hello world"},
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			object, err := unmarshalINI([]byte(test.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(object, test.expected) {
+				t.Errorf("expected %#v, got %#v", test.expected, object)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"github.com/itchyny/gojq"
+	"reflect"
+)
+
+var (
+	jqExpression string
+	jqCode       *gojq.Code
+)
+
+// compileJQ parses the --jq expression, which replaces the JSONPath filtering.
+func compileJQ() error {
+	if jqExpression == "" {
+		return nil
+	}
+	if len(jsonpathTemplates) > 0 {
+		return usageError("--jq cannot be combined with --jsonpath or --jsonpath-file")
+	}
+	code, err := parseJQ(jqExpression)
+	if err != nil {
+		return usageError(fmt.Sprintf("invalid jq expression %q: %v", jqExpression, err))
+	}
+	jqCode = code
+	return nil
+}
+
+func parseJQ(expression string) (*gojq.Code, error) {
+	query, err := gojq.Parse(expression)
+	if err != nil {
+		return nil, err
+	}
+	return gojq.Compile(query)
+}
+
+// runJQ runs the object through the compiled jq expression. Like filter, it returns
+// nil without any output, the value for a single output or jsonpathResults otherwise.
+func runJQ(code *gojq.Code, expression string, object interface{}) (interface{}, error) {
+	object, err := jqValue(object)
+	if err != nil {
+		return nil, fmt.Errorf("error executing jq %q: %v", expression, err)
+	}
+	var results []interface{}
+	iter := code.Run(object)
+	for {
+		value, ok := iter.Next()
+		if !ok {
+			break
+		}
+		if err, ok := value.(error); ok {
+			if halt, ok := err.(*gojq.HaltError); ok && halt.Value() == nil {
+				break
+			}
+			return nil, fmt.Errorf("error executing jq %q: %v", expression, err)
+		}
+		results = append(results, value)
+	}
+	if len(results) == 0 {
+		return nil, nil
+	} else if len(results) == 1 {
+		return results[0], nil
+	} else {
+		return jsonpathResults(results), nil
+	}
+}
+
+// jqValue converts the numbers of the value, in place, to the int and float64
+// gojq works with, as a decoder may leave an int64 or a float32 behind, which
+// gojq cannot handle. Any other type is an error rather than a gojq panic.
+func jqValue(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case nil, bool, int, float64, string:
+		return v, nil
+	case map[string]interface{}:
+		for key, item := range v {
+			converted, err := jqValue(item)
+			if err != nil {
+				return nil, err
+			}
+			v[key] = converted
+		}
+		return v, nil
+	case []interface{}:
+		for i, item := range v {
+			converted, err := jqValue(item)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = converted
+		}
+		return v, nil
+	}
+	switch reflected := reflect.ValueOf(value); reflected.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return preservedInt(reflected.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return preservedInt(reflected.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return reflected.Float(), nil
+	}
+	return nil, fmt.Errorf("unsupported value type %T", value)
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRunJQ(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		object     interface{}
+		expected   interface{}
+		err        string
+	}{
+		{"int64", ".a + 1", map[string]interface{}{"a": int64(1)}, 2, ""},
+		{"unsigned", ".a * 2", map[string]interface{}{"a": uint8(21)}, 42, ""},
+		{"float32", ".a", map[string]interface{}{"a": float32(0.5)}, 0.5, ""},
+		{"nested", ".a[0].b", map[string]interface{}{"a": []interface{}{map[string]interface{}{"b": int32(3)}}}, 3, ""},
+		{"several results", ".[]", []interface{}{int64(1), "two"}, jsonpathResults{1, "two"}, ""},
+		{"no results", "empty", map[string]interface{}{}, nil, ""},
+		{"unsupported", ".a", map[string]interface{}{"a": time.Time{}},
+			nil, `error executing jq ".a": unsupported value type time.Time`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			code, err := parseJQ(test.expression)
+			if err != nil {
+				t.Fatal(err)
+			}
+			result, err := runJQ(code, test.expression, test.object)
+			if test.err != "" {
+				if err == nil || err.Error() != test.err {
+					t.Errorf("expected the error %q, got %v", test.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(result, test.expected) {
+				t.Errorf("expected %#v, got %#v", test.expected, result)
+			}
+		})
+	}
+}
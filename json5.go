@@ -0,0 +1,22 @@
+package main
+
+import (
+	"bytes"
+	"github.com/titanous/json5"
+)
+
+var json5Format = format{unmarshal: unmarshalJSON5}
+
+// unmarshalJSON5 decodes JSON5, with its comments, trailing commas, unquoted
+// keys, single-quoted strings and hexadecimal numbers, into the same
+// structure the JSON decoder produces.
+func unmarshalJSON5(input []byte) (interface{}, error) {
+	if len(bytes.TrimSpace(input)) == 0 {
+		return nil, nil
+	}
+	var object interface{}
+	if err := json5.Unmarshal(input, &object); err != nil {
+		return nil, err
+	}
+	return object, nil
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+var maxInputBytes int64
+
+// limitInput fails the reads of the input once it has more than --max-input-bytes,
+// decompressed, so that a hostile source cannot exhaust the memory.
+func limitInput(inputPath string, input io.ReadCloser) io.ReadCloser {
+	if maxInputBytes <= 0 {
+		return input
+	}
+	return &limitedInput{
+		ReadCloser: input,
+		limited:    io.LimitReader(input, maxInputBytes+1),
+		name:       inputName(inputPath),
+	}
+}
+
+type limitedInput struct {
+	io.ReadCloser
+	limited io.Reader
+	name    string
+	read    int64
+}
+
+func (r *limitedInput) Read(p []byte) (int, error) {
+	n, err := r.limited.Read(p)
+	r.read += int64(n)
+	if r.read > maxInputBytes {
+		return 0, exitError(fmt.Sprintf("%s is larger than the --max-input-bytes limit of %d bytes", r.name, maxInputBytes), exitFailure)
+	}
+	return n, err
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLimitInput(t *testing.T) {
+	tests := []struct {
+		name  string
+		limit int64
+		input string
+		fails bool
+	}{
+		{"unlimited", 0, strings.Repeat("a", 10000), false},
+		{"below the limit", 10, "123456789", false},
+		{"at the limit", 10, "1234567890", false},
+		{"beyond the limit", 10, "12345678901", true},
+		{"far beyond the limit", 10, strings.Repeat("a", 100000), true},
+		{"empty", 1, "", false},
+	}
+	defer func(limit int64) { maxInputBytes = limit }(maxInputBytes)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			maxInputBytes = test.limit
+			content, err := ioutil.ReadAll(limitInput("input.json", ioutil.NopCloser(strings.NewReader(test.input))))
+			if fails := err != nil; fails != test.fails {
+				t.Fatalf("expected failing %v, got %v", test.fails, err)
+			}
+			if err != nil {
+				if !strings.Contains(err.Error(), "input.json is larger than the --max-input-bytes limit of 10 bytes") {
+					t.Errorf("unexpected error %v", err)
+				}
+				return
+			}
+			if string(content) != test.input {
+				t.Errorf("expected the whole input, got %d bytes", len(content))
+			}
+		})
+	}
+}
+
+func TestLimitDecompressedInput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "input.json.gz")
+	if err := ioutil.WriteFile(path, []byte(gzipped(t, strings.Repeat(" ", 1000)+"{}")), 0644); err != nil {
+		t.Fatal(err)
+	}
+	defer func(limit int64) { maxInputBytes = limit }(maxInputBytes)
+	maxInputBytes = 100
+	if _, err := readInput(path); err == nil {
+		t.Error("expected the decompressed input to be limited")
+	}
+	maxInputBytes = 2000
+	if _, err := readInput(path); err != nil {
+		t.Error(err)
+	}
+}
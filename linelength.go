@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+var (
+	maxLineLength int
+	wrapLongLines bool
+)
+
+// checkLineLength fails when a line of the JSON output is longer than the
+// --max-line-length, in bytes. With --wrap-long-lines, a compact output
+// that is too long is indented instead, and only fails when a line of
+// the indented output, such as a long string, is still too long.
+func checkLineLength(output []byte, compact bool) ([]byte, error) {
+	if maxLineLength <= 0 {
+		return output, nil
+	}
+	number, length := longestLine(output)
+	if length > maxLineLength && wrapLongLines && compact {
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, output, "", "  "); err != nil {
+			return nil, err
+		}
+		output = indented.Bytes()
+		number, length = longestLine(output)
+	}
+	if length > maxLineLength {
+		return nil, fmt.Errorf("line %d of the output is %d bytes long, over the --max-line-length of %d", number, length, maxLineLength)
+	}
+	return output, nil
+}
+
+// longestLine returns the number and the length of the longest line.
+func longestLine(output []byte) (int, int) {
+	longest, length := 0, 0
+	for i, line := range bytes.Split(output, []byte("\n")) {
+		if len(line) > length {
+			longest, length = i+1, len(line)
+		}
+	}
+	return longest, length
+}
@@ -1,31 +1,380 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
-	"github.com/Sirupsen/logrus"
 	"github.com/codem8s/2fy/version"
 	"github.com/ghodss/yaml"
+	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli"
 	"io"
 	"io/ioutil"
 	"k8s.io/client-go/util/jsonpath"
 	"os"
-	"encoding/json"
+	"path/filepath"
 	"reflect"
+	"sort"
+	"strconv"
+	"strings"
 )
 
 var (
-	inputPath  string
-	outputPath string
-	jsonpathTemplate string
+	inputPaths        cli.StringSlice
+	outputPath        string
+	appendOutput      bool
+	jsonpathTemplates cli.StringSlice
+	jsonpathFile      string
+	indent            string
+	escapeHTML        = true
+	ndjson            bool
+	failOnEmpty       bool
+	raw               bool
+	countResults      bool
+	validateOnly      bool
+	nullInput         bool
+	writeInPlace      bool
+	outputDir         string
+	outputSuffix      string
+	fromFormat        string
+	toFormat          string
 )
 
+// commonFlags are the flags shared by all the conversion commands.
+var commonFlags = []cli.Flag{
+	cli.StringSliceFlag{
+		Name:  "input, in",
+		Usage: "the input files, glob patterns or http(s) URLs, can be repeated (or stdin otherwise)",
+		Value: &inputPaths,
+	},
+	cli.StringFlag{
+		Name:        "output, out",
+		Usage:       "the output file (or stdout otherwise)",
+		Destination: &outputPath,
+	},
+	cli.BoolFlag{
+		Name:        "append",
+		Usage:       "append to the output files instead of replacing them, ending the output with a newline unless --trailing-newline=false, e.g. to build up a --ndjson stream",
+		Destination: &appendOutput,
+	},
+	cli.BoolFlag{
+		Name:        "write, w",
+		Usage:       "write the result back to the input file",
+		Destination: &writeInPlace,
+	},
+	cli.BoolFlag{
+		Name:        "dry-run",
+		Usage:       "with --write, write the unified diff of the changes instead of the files",
+		Destination: &dryRun,
+	},
+	cli.BoolFlag{
+		Name:        "fail-on-diff",
+		Usage:       "with --dry-run, exit with an error when any file would change",
+		Destination: &failOnDiff,
+	},
+	cli.StringFlag{
+		Name:        "output-dir",
+		Usage:       "the directory to write each input's result to, created when missing (or next to the input otherwise)",
+		Destination: &outputDir,
+	},
+	cli.StringFlag{
+		Name:        "output-suffix",
+		Usage:       "write each input's result to its own file, named after the input with its extension replaced by the suffix (e.g. .json)",
+		Destination: &outputSuffix,
+	},
+	cli.BoolFlag{
+		Name:        "progress",
+		Usage:       "write the number of files processed, and the bytes read with --stream, to stderr when it is a terminal",
+		Destination: &showProgress,
+	},
+	cli.BoolFlag{
+		Name:        "force-progress",
+		Usage:       "write the --progress to stderr even when it is not a terminal",
+		Destination: &forceProgress,
+	},
+	cli.BoolFlag{
+		Name:        "explode",
+		Usage:       "write every document of the inputs to its own file of the --output-dir, doc-0, doc-1... with the --output-suffix",
+		Destination: &explode,
+	},
+	cli.StringFlag{
+		Name:        "name-field",
+		Usage:       "with --explode, name the files after the value at the dotted path of the documents, e.g. metadata.name",
+		Destination: &nameField,
+	},
+	cli.StringSliceFlag{
+		Name:  "jsonpath, jp",
+		Usage: "the optional JSONPath template to parse the input with, can be repeated to apply each template to the result of the previous one",
+		Value: &jsonpathTemplates,
+	},
+	cli.StringFlag{
+		Name:        "jsonpath-file",
+		Usage:       "the file to read the JSONPath template from, instead of --jsonpath",
+		Destination: &jsonpathFile,
+	},
+	cli.StringFlag{
+		Name:        "jsonpath-dialect",
+		Usage:       "the JSONPath engine: 'kubectl' for the {.items[*].metadata.name} templates of kubectl, or 'standard' for the RFC 9535 queries like $.items[*].metadata.name",
+		Value:       jsonpathKubectl,
+		Destination: &jsonpathDialect,
+	},
+	cli.StringFlag{
+		Name:        "jq",
+		Usage:       "the optional jq expression to filter the input with, instead of a JSONPath template",
+		Destination: &jqExpression,
+	},
+	cli.StringFlag{
+		Name:        "select",
+		Usage:       "the optional dotted path of the value to select, e.g. items[0].metadata.name, instead of a JSONPath template",
+		Destination: &selectPath,
+	},
+	cli.Int64Flag{
+		Name:        "max-input-bytes",
+		Usage:       "fail on the inputs larger than the number of bytes, once decompressed, 0 for no limit",
+		Destination: &maxInputBytes,
+	},
+	cli.BoolFlag{
+		Name:        "preserve-int",
+		Usage:       "decode the whole JSON, YAML, TOML, EDN, CBOR and MessagePack numbers as integers instead of floats, so that the large ones keep all of their digits",
+		Destination: &preserveInt,
+	},
+	cli.BoolFlag{
+		Name:        "verify",
+		Usage:       "read every output document back with the decoder of its format, and fail when it does not parse or differs from what was written",
+		Destination: &verifyOutput,
+	},
+	cli.DurationFlag{
+		Name:        "timeout",
+		Usage:       "the time limit of fetching a URL input",
+		Value:       httpTimeout,
+		Destination: &httpTimeout,
+	},
+	cli.IntFlag{
+		Name:        "retry",
+		Usage:       "the number of times to retry fetching a URL input after a connection error or a 5xx response",
+		Destination: &httpRetries,
+	},
+	cli.DurationFlag{
+		Name:        "retry-delay",
+		Usage:       "the delay before the first --retry, doubled before each of the next ones",
+		Value:       httpRetryDelay,
+		Destination: &httpRetryDelay,
+	},
+	cli.BoolFlag{
+		Name:        "from-clipboard",
+		Usage:       "read the input from the system clipboard instead of stdin",
+		Destination: &fromClipboard,
+	},
+	cli.BoolFlag{
+		Name:        "to-clipboard",
+		Usage:       "write the output to the system clipboard instead of stdout",
+		Destination: &toClipboard,
+	},
+	cli.BoolFlag{
+		Name:        "input-tar",
+		Usage:       "read the input as a tar archive, optionally gzipped, of YAML and JSON files, each file being a document",
+		Destination: &inputTar,
+	},
+	cli.IntFlag{
+		Name:        "max-depth",
+		Usage:       "fail when the input nests more than N objects and arrays (unlimited otherwise)",
+		Destination: &maxDepth,
+	},
+	cli.StringFlag{
+		Name:        "input-encoding",
+		Usage:       "the encoding of the input, 'utf-8', 'utf-16le' or 'utf-16be', a leading byte order mark is always stripped",
+		Value:       "utf-8",
+		Destination: &inputEncoding,
+	},
+	cli.StringFlag{
+		Name:        "template",
+		Usage:       "the optional Go text/template to render the result with, instead of the output format",
+		Destination: &templateText,
+	},
+	cli.StringFlag{
+		Name:        "template-file",
+		Usage:       "the file to read the Go text/template from, instead of --template",
+		Destination: &templateFile,
+	},
+	cli.BoolFlag{
+		Name:        "raw-input",
+		Usage:       "do not decode the input, render the --template with its content as a string, e.g. {{ b64enc . }}",
+		Destination: &rawInput,
+	},
+	cli.StringSliceFlag{
+		Name:  "where",
+		Usage: "keep the elements of the top-level array whose dotted path field compares with =, !=, >, >=, < or <=, e.g. spec.replicas>1, can be repeated",
+		Value: &whereExpressions,
+	},
+	cli.StringFlag{
+		Name:        "field-selector",
+		Usage:       "keep the elements of the top-level array, or of the .items of a List, matching the comma-separated field=value or field!=value like kubectl, e.g. status.phase=Running",
+		Destination: &fieldSelector,
+	},
+	cli.StringFlag{
+		Name:        "pipe",
+		Usage:       "the stages to run the input through before the other filters, e.g. \"jsonpath=.spec|set=replicas=3|wrap=spec\", of jsonpath, jq, select, where, set, set-string, pick, omit and wrap",
+		Destination: &pipeSpec,
+	},
+	cli.StringSliceFlag{
+		Name:  "set",
+		Usage: "set the value at the dotted path, e.g. spec.replicas=3, typed as a number, boolean or null when it reads as one, can be repeated",
+		Value: &setValues,
+	},
+	cli.StringSliceFlag{
+		Name:  "set-string",
+		Usage: "set the string value at the dotted path, e.g. metadata.labels.version=1.10, can be repeated",
+		Value: &setStringValues,
+	},
+	cli.BoolFlag{
+		Name:        "concat",
+		Usage:       "combine the inputs into a single array of their contents, in order, that --jsonpath and the other filters apply to",
+		Destination: &concatInputs,
+	},
+	cli.StringFlag{
+		Name:        "pick",
+		Usage:       "keep only the comma-separated dotted paths of the objects, e.g. metadata.name,spec, applied to every element of the arrays",
+		Destination: &pickList,
+	},
+	cli.StringFlag{
+		Name:        "omit",
+		Usage:       "remove the comma-separated dotted paths from the objects, e.g. metadata.managedFields,status, applied to every element of the arrays",
+		Destination: &omitList,
+	},
+	cli.StringFlag{
+		Name:        "wrap-key",
+		Usage:       "nest the result under the dotted key, e.g. data or spec.template, creating the intermediate objects",
+		Destination: &wrapKey,
+	},
+	cli.StringFlag{
+		Name:        "patch",
+		Usage:       "the YAML or JSON file, or - for stdin, to deep-merge onto the input like the merge command does",
+		Destination: &patchPath,
+	},
+	cli.StringFlag{
+		Name:        "schema",
+		Usage:       "the optional JSON Schema file to validate the input against before converting it",
+		Destination: &schemaPath,
+	},
+	cli.BoolFlag{
+		Name:        "null-input, n",
+		Usage:       "do not read any input, render the --template or --jq against an empty object instead",
+		Destination: &nullInput,
+	},
+	cli.BoolFlag{
+		Name:        "validate-only",
+		Usage:       "only check that the input converts, with --schema or --jsonpath and --fail-on-empty, without writing anything",
+		Destination: &validateOnly,
+	},
+	cli.BoolFlag{
+		Name:        "fail-on-empty",
+		Usage:       "exit with an error when the JSONPath template matches nothing",
+		Destination: &failOnEmpty,
+	},
+	cli.BoolFlag{
+		Name:        "count",
+		Usage:       "write the number of values matched by the JSONPath template instead of the values",
+		Destination: &countResults,
+	},
+	cli.BoolFlag{
+		Name:        "strip-nulls",
+		Usage:       "remove the null values from the objects",
+		Destination: &stripNulls,
+	},
+	cli.BoolFlag{
+		Name:        "strip-empty",
+		Usage:       "remove the empty objects and arrays from the objects, including the ones emptied by --strip-nulls",
+		Destination: &stripEmpty,
+	},
+	cli.BoolFlag{
+		Name:        "normalize-quantities",
+		Usage:       "add a sibling key with a Value suffix to the Kubernetes quantities of the resources requests and limits, capacity and allocatable, e.g. memoryValue: 104857600 for memory: 100Mi, or cpuValue: 0.5 for cpu: 500m",
+		Destination: &normalizeQuantities,
+	},
+	cli.BoolFlag{
+		Name:        "with-types",
+		Usage:       "write each result as an object of its JSON type and its value, to debug the JSONPath templates",
+		Destination: &withTypes,
+	},
+	cli.BoolFlag{
+		Name:        "decode-base64",
+		Usage:       "base64-decode the selected string and write the plain content",
+		Destination: &decodeBase64,
+	},
+	cli.BoolFlag{
+		Name:        "encode-base64",
+		Usage:       "base64-encode the selected string, or the marshalled result otherwise",
+		Destination: &encodeBase64,
+	},
+	cli.StringFlag{
+		Name:        "trailing-newline",
+		Usage:       "end the output with exactly one newline when true, or none when false (as the output format does otherwise)",
+		Destination: &trailingNewline,
+	},
+	cli.BoolFlag{
+		Name:        "sort-keys",
+		Usage:       "write the keys of every object in alphabetical order, even with --preserve-order",
+		Destination: &sortKeys,
+	},
+	cli.BoolFlag{
+		Name:        "raw",
+		Usage:       "write a string result without quotes, like jq -r, and each of several JSONPath results on its own line",
+		Destination: &raw,
+	},
+}
+
+// jsonFlags are the flags of the commands producing JSON.
+var jsonFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:        "indent, i",
+		Usage:       "indent the JSON output with the given number of spaces or 'tab'",
+		Destination: &indent,
+	},
+	cli.BoolFlag{
+		Name:        "pretty",
+		Usage:       "indent and colorize the JSON output, the default when writing to a terminal",
+		Destination: &pretty,
+	},
+	cli.BoolFlag{
+		Name:        "ndjson",
+		Usage:       "write each element of a top-level array as its own compact JSON line",
+		Destination: &ndjson,
+	},
+	cli.BoolTFlag{
+		Name:        "escape-html",
+		Usage:       "escape <, > and & in the JSON strings, --escape-html=false writes them as they are",
+		Destination: &escapeHTML,
+	},
+	cli.IntFlag{
+		Name:        "max-line-length",
+		Usage:       "fail when a line of the JSON output is longer than the number of bytes, 0 for no limit",
+		Destination: &maxLineLength,
+	},
+	cli.BoolFlag{
+		Name:        "wrap-long-lines",
+		Usage:       "with --max-line-length, indent the compact JSON output that is too long instead of failing",
+		Destination: &wrapLongLines,
+	},
+}
+
+// flags concatenates the given flag sets into a new slice.
+func flags(sets ...[]cli.Flag) []cli.Flag {
+	var all []cli.Flag
+	for _, set := range sets {
+		all = append(all, set...)
+	}
+	return all
+}
+
 // preload initializes any global options and configuration
 // before the main or sub commands are run.
 func preload(c *cli.Context) (err error) {
 	if c.GlobalBool("debug") {
 		logrus.SetLevel(logrus.DebugLevel)
 	}
+	if err := setupColor(c.GlobalString("color")); err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -37,102 +386,524 @@ func main() {
 	app.Author = "codem8s"
 	app.Email = "no-reply@codemat.es"
 	app.Usage = "convert all the things!"
+	app.Description = "Exits with 0 on success, 1 when an input cannot be converted or a check fails, and 2 on a wrong command line (unknown command, invalid flag, flags that cannot be combined)."
 	app.Before = preload
 	app.Flags = []cli.Flag{
 		cli.BoolFlag{
 			Name:  "debug, d",
 			Usage: "run in debug mode",
 		},
+		cli.StringFlag{
+			Name:  "color",
+			Value: "auto",
+			Usage: "colorize the debug logs and the error messages: 'always', 'never' or 'auto' when stderr is a terminal",
+		},
 	}
 	app.Commands = []cli.Command{
 		{
-			Name:    "yaml2txt",
-			Aliases: []string{"y2t"},
-			Usage:   "conver YAML to a text representation",
+			Name:  "convert",
+			Usage: fmt.Sprintf("convert between any of the formats: %s", strings.Join(formatNames(), ", ")),
+			Flags: flags(commonFlags, []cli.Flag{
+				cli.StringFlag{
+					Name:        "from",
+					Usage:       "the input format, or auto to detect it from the input extensions or else the content: json, xml, toml or yaml",
+					Value:       "auto",
+					Destination: &fromFormat,
+				},
+				cli.StringFlag{
+					Name:        "to",
+					Usage:       "the output format",
+					Value:       "json",
+					Destination: &toFormat,
+				},
+			}, jsonFlags, csvFlags, []cli.Flag{anchorsFlag, csvNoHeaderFlag, docMarkersFlag, envFlattenFlag, preserveCommentsFlag, preserveOrderFlag, propertiesExpandFlag, queryBracketsFlag, quoteStyleFlag, streamFlag, strictFlag, xmlRootFlag, wrapFlag}),
+			Before: inputFromArgs,
+			Action: func(c *cli.Context) error {
+				from, ok := formats[fromFormat]
+				if fromFormat == "auto" {
+					from, ok = detectFormat(inputPaths), true
+					if stream && from.stream == nil {
+						// JSON is YAML too
+						from = yamlFormat
+					}
+				}
+				if !ok || from.unmarshal == nil {
+					return usageError(fmt.Sprintf("cannot read the %q format", fromFormat))
+				}
+				to, ok := formats[toFormat]
+				if !ok || to.marshal == nil {
+					return usageError(fmt.Sprintf("cannot write the %q format", toFormat))
+				}
+				if err := checkAnchors(); err != nil {
+					return err
+				}
+				if preserveComments {
+					if fromFormat != "yaml" && fromFormat != "auto" || toFormat != "yaml" {
+						return usageError("--preserve-comments requires YAML input and YAML output, the comments cannot be written to other formats")
+					}
+					if err := checkPreserveComments("--preserve-comments"); err != nil {
+						return err
+					}
+					return transform(commentFormat, commentFormat)
+				}
+				if anchors == anchorsPreserve {
+					if fromFormat != "yaml" && fromFormat != "auto" || toFormat != "yaml" {
+						return usageError("--anchors preserve requires YAML input and YAML output, the other formats have no anchors")
+					}
+					if err := checkPreserveComments("--anchors preserve"); err != nil {
+						return err
+					}
+					return transform(commentFormat, commentFormat)
+				}
+				return transform(from, to)
+			},
+		},
+		{
+			Name:   "merge",
+			Usage:  "deep-merge YAML or JSON inputs, the later ones overriding the earlier ones",
+			Flags:  flags(commonFlags, mergeFlags, jsonFlags, []cli.Flag{docMarkersFlag, quoteStyleFlag, strictFlag, wrapFlag}),
+			Before: inputFromArgs,
+			Action: func(c *cli.Context) error {
+				to, ok := formats[toFormat]
+				if !ok || to.marshal == nil {
+					return usageError(fmt.Sprintf("cannot write the %q format", toFormat))
+				}
+				return merge(to)
+			},
+		},
+		{
+			Name:  "diff",
+			Usage: "write the structural differences between two inputs, exit with 1 when they differ",
 			Flags: []cli.Flag{
 				cli.StringFlag{
-					Name:        "input, in",
-					Usage:       "the input file (or stdin otherwise)",
-					Destination: &inputPath,
+					Name:        "from",
+					Usage:       "the input format, guessed from the file extensions otherwise",
+					Destination: &fromFormat,
 				},
 				cli.StringFlag{
 					Name:        "output, out",
 					Usage:       "the output file (or stdout otherwise)",
 					Destination: &outputPath,
 				},
-				cli.StringFlag{
-					Name:        "jsonpath, jp",
-					Usage:       "the optional JSONPath template to parse the input with",
-					Destination: &jsonpathTemplate,
-				},
 			},
+			Before: inputFromArgs,
 			Action: func(c *cli.Context) error {
-				return transform(
-					func(input []byte) (interface{}, error) {
-						var object interface{}
-						if err := yaml.Unmarshal(input, &object); err != nil {
-							return nil, err
-						}
-						return object, nil
-					},
-					func(object interface{}) ([]byte, error) {
-						output := []byte(fmt.Sprintf("%v", object))
-						return output, nil
-					})
+				return diff()
 			},
 		},
 		{
-			Name:    "yaml2json",
-			Aliases: []string{"y2j"},
-			Usage:   "conver YAML to JSON",
+			Name:  "fingerprint",
+			Usage: "write the hash of the content of every input, the same whatever its key order, whitespace or format",
 			Flags: []cli.Flag{
 				cli.StringFlag{
-					Name:        "input, in",
-					Usage:       "the input file (or stdin otherwise)",
-					Destination: &inputPath,
+					Name:        "from",
+					Usage:       "the input format, guessed from the file extensions otherwise",
+					Destination: &fromFormat,
+				},
+				cli.StringFlag{
+					Name:        "algo",
+					Value:       "sha256",
+					Usage:       "the hash algorithm, 'sha256' or 'sha1'",
+					Destination: &fingerprintAlgo,
 				},
 				cli.StringFlag{
 					Name:        "output, out",
 					Usage:       "the output file (or stdout otherwise)",
 					Destination: &outputPath,
 				},
+			},
+			Before: inputFromArgs,
+			Action: func(c *cli.Context) error {
+				return fingerprint()
+			},
+		},
+		{
+			Name:   "flatten",
+			Usage:  "flatten the nested YAML or JSON objects into dotted keys, like a.b[0]",
+			Flags:  flags(commonFlags, flattenFlags, jsonFlags),
+			Before: inputFromArgs,
+			Action: func(c *cli.Context) error {
+				return transform(flattenFormat(yamlFormat), jsonFormat)
+			},
+		},
+		{
+			Name:  "paths",
+			Usage: "write the dotted path and the value of every leaf of the YAML or JSON input, like spec.containers[0].image = nginx",
+			Flags: flags(commonFlags, []cli.Flag{
+				cli.BoolTFlag{
+					Name:        "values",
+					Usage:       "write the values after the paths, --values=false writes only the paths",
+					Destination: &pathValues,
+				},
+			}),
+			Before: inputFromArgs,
+			Action: func(c *cli.Context) error {
+				return transform(yamlFormat, pathsFormat)
+			},
+		},
+		{
+			Name:  "schema2example",
+			Usage: "write an example document of a YAML or JSON Schema, of its defaults, examples or placeholder values of their types",
+			Flags: flags(commonFlags, []cli.Flag{
+				cli.StringFlag{
+					Name:        "to",
+					Usage:       "the output format, e.g. yaml or json",
+					Value:       "yaml",
+					Destination: &toFormat,
+				},
+				cli.BoolFlag{
+					Name:        "include-optional",
+					Usage:       "include the optional properties of the objects, not only the required ones",
+					Destination: &includeOptional,
+				},
+			}, jsonFlags, []cli.Flag{preserveOrderFlag}),
+			Before: inputFromArgs,
+			Action: func(c *cli.Context) error {
+				to, ok := formats[toFormat]
+				if !ok || to.marshal == nil {
+					return usageError(fmt.Sprintf("cannot write the %q format", toFormat))
+				}
+				return transform(exampleFormat(yamlFormat), to)
+			},
+		},
+		{
+			Name:  "k8s-secret-decode",
+			Usage: "write the base64-decoded .data and the .stringData of a Kubernetes Secret manifest",
+			Flags: flags(commonFlags, []cli.Flag{
+				cli.StringFlag{
+					Name:        "to",
+					Usage:       "the output format, e.g. json or env",
+					Value:       "json",
+					Destination: &toFormat,
+				},
+			}, jsonFlags),
+			Before: inputFromArgs,
+			Action: func(c *cli.Context) error {
+				to, ok := formats[toFormat]
+				if !ok || to.marshal == nil {
+					return usageError(fmt.Sprintf("cannot write the %q format", toFormat))
+				}
+				return transform(secretFormat(yamlFormat), to)
+			},
+		},
+		{
+			Name:  "k8s-unwrap",
+			Usage: "write the items of the Kubernetes List manifests, e.g. of kubectl get -o yaml, as YAML documents or a JSON array",
+			Flags: flags(commonFlags, []cli.Flag{
+				cli.StringFlag{
+					Name:        "to",
+					Usage:       "the output format, yaml or json",
+					Value:       "yaml",
+					Destination: &toFormat,
+				},
+				docMarkersFlag,
+				wrapFlag,
+			}, jsonFlags),
+			Before: inputFromArgs,
+			Action: func(c *cli.Context) error {
+				to, ok := formats[toFormat]
+				if !ok || to.marshal == nil {
+					return usageError(fmt.Sprintf("cannot write the %q format", toFormat))
+				}
+				if to.separator == yamlDocumentMarker {
+					to.marshal = marshalDocuments(to)
+				}
+				return transform(unwrapFormat(yamlFormat), to)
+			},
+		},
+		{
+			Name:    "yaml2txt",
+			Aliases: []string{"y2t"},
+			Usage:   "conver YAML to a text representation",
+			Flags:   flags(commonFlags, []cli.Flag{strictFlag}),
+			Before:  inputFromArgs,
+			Action: func(c *cli.Context) error {
+				return transform(yamlFormat, textFormat)
+			},
+		},
+		{
+			Name:    "yaml2json",
+			Aliases: []string{"y2j"},
+			Usage:   "conver YAML to JSON",
+			Flags:   flags(commonFlags, jsonFlags, []cli.Flag{preserveOrderFlag, streamFlag, strictFlag}),
+			Before:  inputFromArgs,
+			Action: func(c *cli.Context) error {
+				return transform(yamlFormat, jsonFormat)
+			},
+		},
+		{
+			Name:    "yaml2yaml",
+			Aliases: []string{"y2y"},
+			Usage:   "normalize YAML with sorted keys and two-space indentation, comments are dropped unless --preserve-comments",
+			Flags:   flags(commonFlags, []cli.Flag{anchorsFlag, docMarkersFlag, preserveCommentsFlag, quoteStyleFlag, strictFlag, wrapFlag}),
+			Before:  inputFromArgs,
+			Action: func(c *cli.Context) error {
+				if err := checkAnchors(); err != nil {
+					return err
+				}
+				if preserveComments || anchors == anchorsPreserve {
+					option := "--preserve-comments"
+					if !preserveComments {
+						option = "--anchors preserve"
+					}
+					if err := checkPreserveComments(option); err != nil {
+						return err
+					}
+					return transform(commentFormat, commentFormat)
+				}
+				return transform(yamlFormat, yamlFormat)
+			},
+		},
+		{
+			Name:   "json52json",
+			Usage:  "convert JSON5 to JSON",
+			Flags:  flags(commonFlags, jsonFlags),
+			Before: inputFromArgs,
+			Action: func(c *cli.Context) error {
+				return transform(json5Format, jsonFormat)
+			},
+		},
+		{
+			Name:    "toml2json",
+			Aliases: []string{"t2j"},
+			Usage:   "convert TOML to JSON",
+			Flags:   flags(commonFlags, jsonFlags),
+			Before:  inputFromArgs,
+			Action: func(c *cli.Context) error {
+				return transform(tomlFormat, jsonFormat)
+			},
+		},
+		{
+			Name:   "yaml2toml",
+			Usage:  "convert YAML to TOML",
+			Flags:  flags(commonFlags, []cli.Flag{strictFlag}),
+			Before: inputFromArgs,
+			Action: func(c *cli.Context) error {
+				return transform(yamlFormat, tomlFormat)
+			},
+		},
+		{
+			Name:    "json2toml",
+			Aliases: []string{"j2t"},
+			Usage:   "convert JSON to TOML",
+			Flags:   commonFlags,
+			Before:  inputFromArgs,
+			Action: func(c *cli.Context) error {
+				return transform(jsonFormat, tomlFormat)
+			},
+		},
+		{
+			Name:    "xml2json",
+			Aliases: []string{"x2j"},
+			Usage:   "convert XML to JSON",
+			Flags:   flags(commonFlags, jsonFlags),
+			Before:  inputFromArgs,
+			Action: func(c *cli.Context) error {
+				return transform(xmlFormat, jsonFormat)
+			},
+		},
+		{
+			Name:   "ini2json",
+			Usage:  "convert INI to JSON, a key repeated within a section keeps its last value",
+			Flags:  flags(commonFlags, jsonFlags),
+			Before: inputFromArgs,
+			Action: func(c *cli.Context) error {
+				return transform(iniFormat, jsonFormat)
+			},
+		},
+		{
+			Name:   "json2xml",
+			Usage:  "convert JSON to XML, keys prefixed with @ become attributes and #text the element text",
+			Flags:  flags(commonFlags, []cli.Flag{xmlRootFlag}),
+			Before: inputFromArgs,
+			Action: func(c *cli.Context) error {
+				return transform(jsonFormat, xmlFormat)
+			},
+		},
+		{
+			Name:   "hcl2json",
+			Usage:  "convert HCL to JSON",
+			Flags:  flags(commonFlags, jsonFlags),
+			Before: inputFromArgs,
+			Action: func(c *cli.Context) error {
+				return transform(hclFormat, jsonFormat)
+			},
+		},
+		{
+			Name:   "json2hcl",
+			Usage:  "convert JSON to HCL, objects becoming blocks labelled by the keys of the objects of objects, and arrays of objects repeated blocks",
+			Flags:  commonFlags,
+			Before: inputFromArgs,
+			Action: func(c *cli.Context) error {
+				return transform(jsonFormat, hclFormat)
+			},
+		},
+		{
+			Name:   "properties2json",
+			Usage:  "convert Java properties to JSON",
+			Flags:  flags(commonFlags, jsonFlags, []cli.Flag{propertiesExpandFlag}),
+			Before: inputFromArgs,
+			Action: func(c *cli.Context) error {
+				return transform(propertiesFormat, jsonFormat)
+			},
+		},
+		{
+			Name:   "msgpack2json",
+			Usage:  "convert MessagePack to JSON, stdin must be the raw bytes",
+			Flags:  flags(commonFlags, jsonFlags),
+			Before: inputFromArgs,
+			Action: func(c *cli.Context) error {
+				return transform(msgpackFormat, jsonFormat)
+			},
+		},
+		{
+			Name:   "json2msgpack",
+			Usage:  "convert JSON to MessagePack",
+			Flags:  commonFlags,
+			Before: inputFromArgs,
+			Action: func(c *cli.Context) error {
+				return transform(jsonFormat, msgpackFormat)
+			},
+		},
+		{
+			Name:   "cbor2json",
+			Usage:  "convert CBOR to JSON, stdin must be the raw bytes",
+			Flags:  flags(commonFlags, jsonFlags),
+			Before: inputFromArgs,
+			Action: func(c *cli.Context) error {
+				return transform(cborFormat, jsonFormat)
+			},
+		},
+		{
+			Name:   "proto2json",
+			Usage:  "convert a binary protobuf message to JSON, stdin must be the raw bytes",
+			Flags:  flags(commonFlags, protoFlags, jsonFlags),
+			Before: inputFromArgs,
+			Action: func(c *cli.Context) error {
+				if err := loadProtoMessage(); err != nil {
+					return err
+				}
+				return transform(protoFormat, jsonFormat)
+			},
+		},
+		{
+			Name:   "edn2json",
+			Usage:  "convert EDN to JSON, keywords and symbols become strings, sets arrays and tagged literals their value",
+			Flags:  flags(commonFlags, jsonFlags),
+			Before: inputFromArgs,
+			Action: func(c *cli.Context) error {
+				return transform(ednFormat, jsonFormat)
+			},
+		},
+		{
+			Name:   "env2json",
+			Usage:  "convert a dotenv file to JSON",
+			Flags:  flags(commonFlags, jsonFlags),
+			Before: inputFromArgs,
+			Action: func(c *cli.Context) error {
+				return transform(envFormat, jsonFormat)
+			},
+		},
+		{
+			Name:   "json2env",
+			Usage:  "convert a flat JSON object to a dotenv file",
+			Flags:  flags(commonFlags, []cli.Flag{envFlattenFlag}),
+			Before: inputFromArgs,
+			Action: func(c *cli.Context) error {
+				return transform(jsonFormat, envFormat)
+			},
+		},
+		{
+			Name:   "query2json",
+			Usage:  "convert a URL query string to a JSON object of strings, the repeated keys to arrays",
+			Flags:  flags(commonFlags, jsonFlags, []cli.Flag{queryBracketsFlag}),
+			Before: inputFromArgs,
+			Action: func(c *cli.Context) error {
+				return transform(queryFormat, jsonFormat)
+			},
+		},
+		{
+			Name:   "json2query",
+			Usage:  "convert a flat JSON object to a URL-encoded query string, the arrays to repeated keys",
+			Flags:  flags(commonFlags, []cli.Flag{queryBracketsFlag}),
+			Before: inputFromArgs,
+			Action: func(c *cli.Context) error {
+				return transform(jsonFormat, queryFormat)
+			},
+		},
+		{
+			Name:   "yaml2env",
+			Usage:  "convert a YAML object, e.g. selected with --jsonpath, to 'export KEY=VALUE' lines for eval $(2fy yaml2env ...)",
+			Flags:  flags(commonFlags, []cli.Flag{envFlattenFlag}),
+			Before: inputFromArgs,
+			Action: func(c *cli.Context) error {
+				return transform(yamlFormat, shellFormat)
+			},
+		},
+		{
+			Name:    "csv2json",
+			Aliases: []string{"c2j"},
+			Usage:   "convert CSV to JSON",
+			Flags:   flags(commonFlags, jsonFlags, csvFlags, []cli.Flag{csvNoHeaderFlag}),
+			Before:  inputFromArgs,
+			Action: func(c *cli.Context) error {
+				return transform(csvFormat, jsonFormat)
+			},
+		},
+		{
+			Name:    "json2csv",
+			Aliases: []string{"j2c"},
+			Usage:   "convert a JSON array of objects to CSV",
+			Flags:   flags(commonFlags, csvFlags),
+			Before:  inputFromArgs,
+			Action: func(c *cli.Context) error {
+				return transform(jsonFormat, csvFormat)
+			},
+		},
+		{
+			Name:   "json2table",
+			Usage:  "convert a JSON array of objects to an aligned text table",
+			Flags:  flags(commonFlags, tableFlags),
+			Before: inputFromArgs,
+			Action: func(c *cli.Context) error {
+				return transform(jsonFormat, tableFormat)
+			},
+		},
+		{
+			Name:  "json2go",
+			Usage: "convert JSON to Go struct types, with exported camel-cased fields, and a variable holding the JSON as their literal",
+			Flags: flags(commonFlags, []cli.Flag{
 				cli.StringFlag{
-					Name:        "jsonpath, jp",
-					Usage:       "the optional JSONPath template to parse the input with",
-					Destination: &jsonpathTemplate,
+					Name:        "type-name",
+					Usage:       "the name of the top-level struct type, the nested ones being named after it and their fields",
+					Value:       "Generated",
+					Destination: &goTypeName,
 				},
+			}),
+			Before: inputFromArgs,
+			Action: func(c *cli.Context) error {
+				return transform(jsonFormat, goFormat)
 			},
+		},
+		{
+			Name:    "json2yaml",
+			Aliases: []string{"j2y"},
+			Usage:   "convert JSON to YAML",
+			Flags:   flags(commonFlags, []cli.Flag{docMarkersFlag, quoteStyleFlag, streamFlag, wrapFlag}),
+			Before:  inputFromArgs,
 			Action: func(c *cli.Context) error {
-				return transform(
-					func(input []byte) (interface{}, error) {
-						var object interface{}
-						if err := yaml.Unmarshal(input, &object); err != nil {
-							return nil, err
-						}
-						return object, nil
-					},
-					func(object interface{}) ([]byte, error) {
-						output, err := json.Marshal(object)
-						if err != nil {
-							return nil, err
-						}
-						return output, nil
-					})
+				return transform(jsonFormat, yamlFormat)
 			},
 		},
 	}
 
 	app.CommandNotFound = func(c *cli.Context, command string) {
-		fmt.Fprintf(cli.ErrWriter, "There is no %q command.\n", command)
-		cli.OsExiter(1)
+		fmt.Fprintf(cli.ErrWriter, "%sThere is no %q command.\n", errorPrefix, command)
+		cli.OsExiter(exitUsage)
 	}
-	app.OnUsageError = func(c *cli.Context, err error, isSubcommand bool) error {
-		if isSubcommand {
-			return err
-		}
-
-		fmt.Fprintf(cli.ErrWriter, "WRONG: %v\n", err)
-		return nil
+	app.OnUsageError = onUsageError
+	for i := range app.Commands {
+		app.Commands[i].OnUsageError = onUsageError
 	}
 	cli.OsExiter = func(c int) {
 		if c != 0 {
@@ -142,21 +913,77 @@ func main() {
 	}
 
 	if err := app.Run(os.Args); err != nil {
-		fmt.Fprintf(cli.ErrWriter, "ERROR: %v\n", err)
-		cli.OsExiter(1)
+		fmt.Fprintf(cli.ErrWriter, "%s%v\n", errorPrefix, err)
+		cli.OsExiter(exitFailure)
 	}
 }
 
-func readInput() ([]byte, error) {
-	var inputFile *os.File
+// inputFromArgs uses the positional arguments of a command
+// as the input paths, unless the --input flag is given.
+func inputFromArgs(c *cli.Context) error {
+	if len(inputPaths) == 0 && c.Args().Present() {
+		inputPaths = append(inputPaths, c.Args()...)
+	}
+	return nil
+}
+
+// inputName describes the input in messages.
+func inputName(inputPath string) string {
+	if inputPath == "" && fromClipboard {
+		return "clipboard"
+	}
 	if inputPath == "" {
+		return "stdin"
+	}
+	return inputPath
+}
+
+// expandInputs resolves the glob patterns among the input paths, keeping
+// the order in which they were given. Paths without any glob meta
+// characters are kept as they are, so that a missing file is reported
+// when it is opened.
+func expandInputs(patterns []string) ([]string, error) {
+	var paths []string
+	for _, pattern := range patterns {
+		if isURL(pattern) || !strings.ContainsAny(pattern, "*?[") {
+			paths = append(paths, pattern)
+			continue
+		}
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no input files match %q", pattern)
+		}
+		logrus.Debugf("input pattern %q matches %v", pattern, matches)
+		paths = append(paths, matches...)
+	}
+	return paths, nil
+}
+
+// openInput opens the input file, or stdin when there is no input path.
+func openInput(inputPath string) (io.ReadCloser, error) {
+	var input io.ReadCloser
+	if inputPath == "" && fromClipboard {
+		clipboardInput, err := readClipboard()
+		if err != nil {
+			return nil, err
+		}
+		input = clipboardInput
+	} else if inputPath == "" {
 		stdinFileInfo, _ := os.Stdin.Stat()
-		if (stdinFileInfo.Mode() & os.ModeNamedPipe) != 0 {
-			logrus.Debug("no input path, using piped stdin")
-			inputFile = os.Stdin
-		} else {
-			return nil, cli.NewExitError("Expected a pipe stdin", 1)
+		if (stdinFileInfo.Mode() & os.ModeNamedPipe) == 0 {
+			return nil, usageError("Expected a pipe stdin")
 		}
+		logrus.Debug("no input path, using piped stdin")
+		input = ioutil.NopCloser(os.Stdin)
+	} else if isURL(inputPath) {
+		body, err := openURL(inputPath)
+		if err != nil {
+			return nil, err
+		}
+		input = body
 	} else {
 		logrus.Debugf("input path: %v", inputPath)
 		f, err := os.Open(inputPath)
@@ -164,9 +991,24 @@ func readInput() ([]byte, error) {
 			logrus.Debug("cannot open file")
 			return nil, err
 		}
-		defer f.Close()
-		inputFile = f
+		input = f
+	}
+	input, err := decompressInput(input)
+	if err != nil {
+		return nil, err
+	}
+	if input, err = decodeInput(input); err != nil {
+		return nil, err
+	}
+	return limitInput(inputPath, input), nil
+}
+
+func readInput(inputPath string) ([]byte, error) {
+	inputFile, err := openInput(inputPath)
+	if err != nil {
+		return nil, err
 	}
+	defer inputFile.Close()
 	fileContent, err := ioutil.ReadAll(inputFile)
 	if err != nil {
 		logrus.Debug("cannot read file")
@@ -175,7 +1017,11 @@ func readInput() ([]byte, error) {
 	return fileContent, nil
 }
 
-func writeOutput(outputContent []byte) error {
+func writeOutput(outputPath string, outputContent []byte) error {
+	outputContent = withTrailingNewline(outputContent)
+	if outputPath == "" && toClipboard {
+		return writeClipboard(outputContent)
+	}
 	if outputPath == "" {
 		logrus.Debug("no output path, writing to stdout")
 		count, err := os.Stdout.Write(outputContent)
@@ -189,7 +1035,12 @@ func writeOutput(outputContent []byte) error {
 		}
 	} else {
 		logrus.Debugf("writing to file: %v", outputPath)
-		err := ioutil.WriteFile(outputPath, outputContent, 0644)
+		var err error
+		if appendOutput {
+			err = appendFile(outputPath, outputContent)
+		} else {
+			err = writeFileAtomic(outputPath, outputContent, outputMode(outputPath))
+		}
 		if err != nil {
 			logrus.Debug("error writing to file")
 			return err
@@ -198,80 +1049,907 @@ func writeOutput(outputContent []byte) error {
 	return nil
 }
 
-func collectResults(cr []interface{}, results []reflect.Value) []interface{} {
-	for _, r := range results {
-		cr = append(cr, r.Interface())
+// writeStream lets write produce the output incrementally,
+// into the output file or stdout when there is no output path.
+func writeStream(outputPath string, write func(io.Writer) error) error {
+	if trailingNewline != "" {
+		stream := write
+		write = func(w io.Writer) error {
+			newlines := &newlineWriter{writer: w}
+			if err := stream(newlines); err != nil {
+				return err
+			}
+			return newlines.Close()
+		}
 	}
-	return cr
+	if outputPath == "" && toClipboard {
+		return streamClipboard(write)
+	}
+	if outputPath == "" {
+		logrus.Debug("no output path, streaming to stdout")
+		return write(os.Stdout)
+	}
+	logrus.Debugf("streaming to file: %v", outputPath)
+	if appendOutput {
+		return appendFileFunc(outputPath, write)
+	}
+	return writeFileAtomicFunc(outputPath, outputMode(outputPath), write)
 }
 
-func filter(object interface{}, jsonpathTemplate string) (interface{}, error) {
-	if jsonpathTemplate != "" {
-		jp := jsonpath.New("out")
-		if err := jp.Parse(jsonpathTemplate); err != nil {
-			return nil, err
-		}
-		logrus.Debugf("JSON Path template: '%v'", jsonpathTemplate)
-
-		fullResults, err1 := jp.FindResults(object)
-		if err1 != nil {
-			logrus.Debugf(
-					"Error executing template: %v. Printing more information for debugging the template:\n" +
-						"\ttemplate was:\n\t\t%v\n" +
-						"\tobject given to jsonpath engine was:\n\t\t%#v\n\n", err1, jsonpathTemplate, object)
-			return nil, fmt.Errorf("error executing jsonpath %q: %v", jsonpathTemplate, err1)
-		}
-
-		var rs []interface{}
-		for ix := range fullResults {
-			rs = collectResults(rs, fullResults[ix])
-		}
-		if len(rs) == 0 {
-			return nil, nil
-		} else if len(rs) == 1 {
-			return rs[0], nil
-		} else {
-			return rs, nil
-		}
-	} else {
-		logrus.Debug("No results found for the JSON Path")
-		return object, nil
+// outputMode returns the mode of the existing output file, or 0644 for a new one.
+func outputMode(outputPath string) os.FileMode {
+	if info, err := os.Stat(outputPath); err == nil {
+		logrus.Debugf("keeping the mode of the existing file: %v", info.Mode().Perm())
+		return info.Mode().Perm()
 	}
+	return 0644
+}
+
+// writeFileAtomic writes the content to a temporary file next to the target
+// and renames it into place, so the target never holds partial content.
+func writeFileAtomic(path string, content []byte, perm os.FileMode) error {
+	return writeFileAtomicFunc(path, perm, func(w io.Writer) error {
+		_, err := w.Write(content)
+		return err
+	})
+}
+
+// appendFile appends the content to the file, created when missing,
+// for --append. The appended content is not atomic.
+func appendFile(path string, content []byte) error {
+	return appendFileFunc(path, func(w io.Writer) error {
+		_, err := w.Write(content)
+		return err
+	})
+}
+
+// appendFileFunc is appendFile with the content produced by write. A .gz
+// file gets another gzip member, which the gzip readers read on from the first.
+func appendFileFunc(path string, write func(io.Writer) error) error {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	err = compressOutput(path, write)(file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// writeFileAtomicFunc is writeFileAtomic with the content produced by write.
+// When the temporary file cannot be created it writes the target directly.
+// The content is gzip compressed when the path ends in .gz.
+func writeFileAtomicFunc(path string, perm os.FileMode, write func(io.Writer) error) error {
+	write = compressOutput(path, write)
+	tempFile, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".")
+	if err != nil {
+		logrus.Debugf("cannot create a temporary file, writing directly: %v", err)
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+		if err != nil {
+			return err
+		}
+		err = write(file)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		return err
+	}
+	tempPath := tempFile.Name()
+	logrus.Debugf("writing to temporary file: %v", tempPath)
+
+	err = write(tempFile)
+	if err == nil {
+		err = tempFile.Sync()
+	}
+	if closeErr := tempFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tempPath, perm)
+	}
+	if err == nil {
+		err = os.Rename(tempPath, path)
+	}
+	if err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+	return nil
+}
+
+func collectResults(cr []interface{}, results []reflect.Value) []interface{} {
+	for _, r := range results {
+		cr = append(cr, r.Interface())
+	}
+	return cr
+}
+
+// loadJSONPath reads the JSONPath template from the --jsonpath-file, if given.
+func loadJSONPath() error {
+	if jsonpathFile == "" {
+		return nil
+	}
+	if len(jsonpathTemplates) > 0 {
+		return usageError("--jsonpath and --jsonpath-file cannot be combined")
+	}
+	content, err := ioutil.ReadFile(jsonpathFile)
+	if err != nil {
+		return err
+	}
+	// editors usually end the file with a newline
+	template := strings.TrimSuffix(strings.TrimSuffix(string(content), "\n"), "\r")
+	jsonpathTemplates = append(jsonpathTemplates, template)
+	logrus.Debugf("JSON Path template read from %v", jsonpathFile)
+	return nil
+}
+
+// jsonpathResults holds the values of a JSONPath template with several matches,
+// as opposed to a single match that happens to be an array.
+type jsonpathResults []interface{}
+
+// filterAll applies the JSONPath templates in the order they were given,
+// each one to the result of the previous one. An empty intermediate
+// result ends the chain with an empty result.
+func filterAll(object interface{}, templates []string) (interface{}, error) {
+	for _, template := range templates {
+		if results, ok := object.(jsonpathResults); ok {
+			object = []interface{}(results)
+		}
+		var err error
+		object, err = filter(object, template)
+		if err != nil || object == nil {
+			return nil, err
+		}
+	}
+	return object, nil
+}
+
+func filter(object interface{}, jsonpathTemplate string) (interface{}, error) {
+	if jsonpathTemplate != "" && jsonpathDialect == jsonpathStandard {
+		return filterStandard(object, jsonpathTemplate)
+	}
+	if jsonpathTemplate != "" {
+		jp := jsonpath.New("out")
+		if err := jp.Parse(jsonpathTemplate); err != nil {
+			return nil, err
+		}
+		logrus.Debugf("JSON Path template: '%v'", jsonpathTemplate)
+
+		fullResults, err1 := jp.FindResults(object)
+		if err1 != nil {
+			logrus.Debugf(
+				"Error executing template: %v. Printing more information for debugging the template:\n"+
+					"\ttemplate was:\n\t\t%v\n"+
+					"\tobject given to jsonpath engine was:\n\t\t%#v\n\n", err1, jsonpathTemplate, object)
+			return nil, fmt.Errorf("error executing jsonpath %q: %v", jsonpathTemplate, err1)
+		}
+
+		var rs []interface{}
+		for ix := range fullResults {
+			rs = collectResults(rs, fullResults[ix])
+		}
+		if len(rs) == 0 {
+			return nil, nil
+		} else if len(rs) == 1 {
+			return rs[0], nil
+		} else {
+			return jsonpathResults(rs), nil
+		}
+	} else {
+		logrus.Debug("No results found for the JSON Path")
+		return object, nil
+	}
+}
+
+// marshalRaw writes a string as is, without the quotes of the output
+// format, like jq -r, and marshals any other value.
+func marshalRaw(value interface{}, marshal marshaller) ([]byte, error) {
+	if s, ok := value.(string); ok {
+		return []byte(s), nil
+	}
+	return marshal(value)
+}
+
+// marshalEach marshals every JSONPath result on its own, one per line,
+// the strings unquoted.
+func marshalEach(results jsonpathResults, marshal marshaller) ([]byte, error) {
+	lines := make([][]byte, len(results))
+	for i, result := range results {
+		line, err := marshalRaw(result, marshal)
+		if err != nil {
+			return nil, err
+		}
+		lines[i] = bytes.TrimSuffix(line, []byte("\n"))
+	}
+	return bytes.Join(lines, []byte("\n")), nil
 }
 
 type unmarshaller func([]byte) (interface{}, error)
 type marshaller func(interface{}) ([]byte, error)
 
-func transform(unmarshal unmarshaller, marshal marshaller) error {
-	inputContent, err := readInput()
+// format groups the functions converting one data format from and to objects.
+type format struct {
+	unmarshal unmarshaller
+	marshal   marshaller
+	// stream decodes the documents of the input one at a time, when supported
+	stream streamDecoder
+	// separator is written between the documents converted from several inputs
+	separator string
+	// stringValues is set for the formats that decode every scalar as a string
+	stringValues bool
+}
+
+var (
+	yamlFormat = format{unmarshal: unmarshalYAML, marshal: marshalYAML, stream: streamYAML, separator: yamlDocumentMarker}
+	jsonFormat = format{unmarshal: unmarshalJSON, marshal: marshalJSON, stream: streamJSON, separator: "\n"}
+	textFormat = format{marshal: marshalText, separator: "\n"}
+)
+
+// documentSeparator is the separator written between the documents, none
+// when the marshaller already starts each of them with it per --doc-markers.
+func (f format) documentSeparator() string {
+	if docMarkers && f.separator == yamlDocumentMarker {
+		return ""
+	}
+	return f.separator
+}
+
+// formats are the formats the convert command can read or write, by name.
+var formats = map[string]format{
+	"yaml":       yamlFormat,
+	"json":       jsonFormat,
+	"json5":      json5Format,
+	"text":       textFormat,
+	"table":      tableFormat,
+	"toml":       tomlFormat,
+	"xml":        xmlFormat,
+	"csv":        csvFormat,
+	"ini":        iniFormat,
+	"edn":        ednFormat,
+	"env":        envFormat,
+	"shell":      shellFormat,
+	"hcl":        hclFormat,
+	"cbor":       cborFormat,
+	"msgpack":    msgpackFormat,
+	"properties": propertiesFormat,
+	"query":      queryFormat,
+}
+
+// formatNames returns the sorted names of the supported formats.
+func formatNames() []string {
+	names := make([]string, 0, len(formats))
+	for name := range formats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// unmarshalYAML decodes every document of the input. A single document is
+// returned as is, several documents are returned as a slice in stream order.
+func unmarshalYAML(input []byte) (interface{}, error) {
+	if preserveOrder {
+		return unmarshalOrderedYAML(input)
+	}
+	var objects []interface{}
+	reader := newYAMLReader(bytes.NewReader(input))
+	for {
+		document, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := checkStrictYAML(document); err != nil {
+			return nil, yamlError(err, reader.start)
+		}
+		var object interface{}
+		if err := decodeYAML(document, &object); err != nil {
+			return nil, yamlError(err, reader.start)
+		}
+		if object != nil {
+			objects = append(objects, object)
+		}
+	}
+	logrus.Debugf("decoded %d YAML documents", len(objects))
+
+	yamlStreamDocuments = len(objects)
+	if len(objects) == 0 {
+		return nil, nil
+	} else if len(objects) == 1 {
+		return objects[0], nil
+	} else {
+		return objects, nil
+	}
+}
+
+func unmarshalJSON(input []byte) (interface{}, error) {
+	// an empty document is not valid JSON, treat it as no object at all
+	if len(bytes.TrimSpace(input)) == 0 {
+		return nil, nil
+	}
+	var object interface{}
+	if err := decodeJSON(input, &object); err != nil {
+		return nil, jsonError(err, input)
+	}
+	return object, nil
+}
+
+// jsonError adds the line and column of the offending input to the JSON decoding errors.
+func jsonError(err error, input []byte) error {
+	var offset int64
+	switch e := err.(type) {
+	case *json.SyntaxError:
+		offset = e.Offset
+	case *json.UnmarshalTypeError:
+		offset = e.Offset
+	default:
+		return err
+	}
+	if offset > int64(len(input)) {
+		offset = int64(len(input))
+	}
+	before := input[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := len(before) - bytes.LastIndexByte(before, '\n')
+	return fmt.Errorf("line %d, column %d: %v", line, column, err)
+}
+
+func marshalText(object interface{}) ([]byte, error) {
+	output := []byte(fmt.Sprintf("%v", object))
+	return output, nil
+}
+
+// marshalNDJSON writes every element of an array on its own compact JSON line,
+// and any other value on a single line.
+func marshalNDJSON(object interface{}) ([]byte, error) {
+	items, ok := object.([]interface{})
+	if !ok {
+		items = []interface{}{object}
+	}
+	lines := make([][]byte, len(items))
+	for i, item := range items {
+		var err error
+		if preserveOrder {
+			lines[i], err = marshalOrderedJSON(item)
+		} else {
+			lines[i], err = encodeJSON(item)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return bytes.Join(lines, []byte("\n")), nil
+}
+
+// encodeJSON is json.Marshal, which leaves <, > and & alone with --escape-html=false.
+func encodeJSON(value interface{}) ([]byte, error) {
+	if escapeHTML {
+		return json.Marshal(value)
+	}
+	var output bytes.Buffer
+	encoder := json.NewEncoder(&output)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(output.Bytes(), []byte("\n")), nil
+}
+
+func marshalJSON(object interface{}) ([]byte, error) {
+	if ndjson {
+		output, err := marshalNDJSON(object)
+		if err != nil {
+			return nil, err
+		}
+		return checkLineLength(output, false)
+	}
+	prefix, err := jsonIndent()
+	if err != nil {
+		return nil, err
+	}
+	var output []byte
+	if preserveOrder {
+		output, err = marshalOrderedJSON(object)
+	} else {
+		output, err = encodeJSON(object)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if prettyJSON() && prefix == "" {
+		prefix = "  "
+	}
+	if prefix != "" {
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, output, "", prefix); err != nil {
+			return nil, err
+		}
+		output = indented.Bytes()
+	}
+	if output, err = checkLineLength(output, prefix == ""); err != nil {
+		return nil, err
+	}
+	if prettyJSON() && outputIsTerminal() {
+		output = colorizeJSON(output)
+	}
+	return output, nil
+}
+
+// jsonIndent translates the --indent flag into the string used for
+// one level of indentation, an empty string means compact output.
+func jsonIndent() (string, error) {
+	if indent == "" {
+		return "", nil
+	}
+	if indent == "tab" {
+		return "\t", nil
+	}
+	spaces, err := strconv.Atoi(indent)
+	if err != nil || spaces < 0 {
+		return "", usageError(fmt.Sprintf("invalid indent %q, expected a number of spaces or 'tab'", indent))
+	}
+	return strings.Repeat(" ", spaces), nil
+}
+
+func marshalYAML(object interface{}) ([]byte, error) {
+	var output []byte
+	var err error
+	if quoteStyle != "" && quoteStyle != "plain" {
+		output, err = marshalQuotedYAML(object)
+	} else if lineWrap != yamlLineWidth && lineWrap != 0 {
+		output, err = marshalFoldedYAML(object)
+	} else {
+		output, err = yaml.Marshal(object)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if docMarkers {
+		output = append([]byte(yamlDocumentMarker), output...)
+	}
+	return output, nil
+}
+
+// prepareFilters loads and validates the options that filter and render the inputs.
+func prepareFilters() error {
+	if err := loadJSONPath(); err != nil {
+		return err
+	}
+	if err := checkJSONPathDialect(); err != nil {
+		return err
+	}
+	if err := compileJQ(); err != nil {
+		return err
+	}
+	if err := parseSelect(); err != nil {
+		return err
+	}
+	if err := parseWhere(); err != nil {
+		return err
+	}
+	if err := parseFieldSelector(); err != nil {
+		return err
+	}
+	if err := parsePick(); err != nil {
+		return err
+	}
+	if err := parsePipe(); err != nil {
+		return err
+	}
+	if err := compileSchema(); err != nil {
+		return err
+	}
+	if err := loadPatch(); err != nil {
+		return err
+	}
+	if err := checkTrailingNewline(); err != nil {
+		return err
+	}
+	if err := checkBase64(); err != nil {
+		return err
+	}
+	if err := setupLineWrap(); err != nil {
+		return err
+	}
+	if fromClipboard && len(inputPaths) > 0 {
+		return usageError("--from-clipboard cannot be combined with input files")
+	}
+	if toClipboard && outputPath != "" {
+		return usageError("--to-clipboard cannot be combined with --output")
+	}
+	return parseTemplate()
+}
+
+// transform converts every input from one format to the other
+// and writes the converted documents as a single output.
+func transform(from, to format) error {
+	if err := prepareFilters(); err != nil {
+		return err
+	}
+	if err := setupVerify(to); err != nil {
+		return err
+	}
+	if nullInput {
+		return transformNullInput(to)
+	}
+	paths, err := expandInputs(inputPaths)
+	if err != nil {
+		return err
+	}
+	startProgress(len(paths))
+	defer stopProgress()
+	if (dryRun || failOnDiff) && !writeInPlace {
+		return usageError("--dry-run and --fail-on-diff require --write")
+	}
+	if appendOutput && writeInPlace {
+		return usageError("--append cannot be combined with --write, which replaces the input files")
+	}
+	if appendOutput && trailingNewline == "" {
+		// keep the appended outputs apart
+		trailingNewline = "true"
+	}
+	if concatInputs && (writeInPlace || outputDir != "" || outputSuffix != "" || stream) {
+		return usageError("--concat cannot be combined with --write, --output-dir, --output-suffix or --stream")
+	}
+	if validateOnly {
+		return validateInputs(paths, from, to)
+	}
+	if writeInPlace {
+		return transformInPlace(paths, from, to)
+	}
+	if explode {
+		return transformExplode(paths, from, to)
+	}
+	if outputDir != "" || outputSuffix != "" {
+		return transformEach(paths, from, to)
+	}
+	if len(paths) == 0 {
+		// no input paths, use stdin
+		paths = []string{""}
+	}
+	if stream {
+		return transformStream(paths, from, to)
+	}
+	if concatInputs {
+		return transformConcat(paths, from, to)
+	}
+
+	var documents [][]byte
+	for _, path := range paths {
+		converted, err := convertDocuments(path, from.unmarshal, to.marshal)
+		if err != nil {
+			return err
+		}
+		documents = append(documents, converted...)
+	}
+	return writeOutput(outputPath, bytes.Join(documents, []byte(to.documentSeparator())))
+}
+
+// transformNullInput renders an empty object instead of reading any input,
+// for the --template or --jq to generate the output from scratch.
+func transformNullInput(to format) error {
+	if len(inputPaths) > 0 {
+		return usageError("--null-input cannot be combined with input files")
+	}
+	output, err := render(map[string]interface{}{}, to.marshal)
 	if err != nil {
 		return err
 	}
+	if output == nil {
+		return emptyResult()
+	}
+	return writeOutput(outputPath, output)
+}
+
+// validateInputs converts every input, stdin without input paths,
+// and discards the results.
+func validateInputs(paths []string, from, to format) error {
+	if len(paths) == 0 {
+		paths = []string{""}
+	}
+	for _, path := range paths {
+		if _, err := convert(path, from.unmarshal, to.marshal); err != nil {
+			return err
+		}
+		logrus.Debugf("%v is valid", inputName(path))
+	}
+	return nil
+}
+
+// transformEach writes the result of every input to its own file,
+// per --output-dir and --output-suffix.
+func transformEach(paths []string, from, to format) error {
+	if len(paths) == 0 {
+		return usageError("--output-dir and --output-suffix require input files, not stdin")
+	}
+	if outputPath != "" {
+		return usageError("--output-dir and --output-suffix cannot be combined with --output")
+	}
+	if outputSuffix == "" {
+		return usageError("--output-dir requires --output-suffix to name the output files")
+	}
+	outputs := make([]string, len(paths))
+	written := map[string]string{}
+	for i, path := range paths {
+		dir := outputDir
+		if dir == "" {
+			dir = filepath.Dir(path)
+		}
+		base := filepath.Base(path)
+		output := filepath.Join(dir, strings.TrimSuffix(base, filepath.Ext(base))+outputSuffix)
+		if output == filepath.Clean(path) {
+			return usageError(fmt.Sprintf("the output of %s would overwrite it, use --write for that", path))
+		}
+		if other, ok := written[output]; ok {
+			return usageError(fmt.Sprintf("%s and %s would both be written to %s", other, path, output))
+		}
+		written[output] = path
+		outputs[i] = output
+	}
+	if outputDir != "" {
+		if err := os.MkdirAll(outputDir, 0755); err != nil {
+			return err
+		}
+	}
+	for i, path := range paths {
+		document, err := convert(path, from.unmarshal, to.marshal)
+		if err != nil {
+			return err
+		}
+		logrus.Debugf("writing %v to %v", path, outputs[i])
+		if err := writeOutput(outputs[i], document); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// transformInPlace converts every input file and writes the result back to it.
+func transformInPlace(paths []string, from, to format) error {
+	if len(paths) == 0 {
+		return usageError("--write requires an input file, cannot write back to stdin")
+	}
+	if outputPath != "" || outputDir != "" || outputSuffix != "" {
+		return usageError("--write cannot be combined with --output, --output-dir or --output-suffix")
+	}
+	changes := 0
+	for _, path := range paths {
+		if isURL(path) {
+			return usageError(fmt.Sprintf("--write cannot write back to the URL %s", path))
+		}
+		document, err := convert(path, from.unmarshal, to.marshal)
+		if err != nil {
+			return err
+		}
+		if dryRun {
+			changed, err := previewWrite(path, document)
+			if err != nil {
+				return err
+			}
+			if changed {
+				changes++
+			}
+			continue
+		}
+		if err := writeOutput(path, document); err != nil {
+			return err
+		}
+	}
+	if failOnDiff && changes > 0 {
+		return exitError(fmt.Sprintf("%d of %d files would change", changes, len(paths)), exitFailure)
+	}
+	return nil
+}
+
+// emptyResult returns the error to report when there is nothing to output, which is
+// only an error when a JSONPath or jq expression is expected to match with --fail-on-empty.
+func emptyResult() error {
+	if failOnEmpty && jqExpression != "" {
+		return exitError(fmt.Sprintf("no results found for the jq expression %q", jqExpression), exitFailure)
+	}
+	if failOnEmpty && len(jsonpathTemplates) > 0 {
+		return exitError(fmt.Sprintf("no results found for the JSON Path %q", strings.Join(jsonpathTemplates, " | ")), exitFailure)
+	}
+	return nil
+}
+
+// convert reads, filters and marshals a single input,
+// it returns nil when there is nothing to output.
+func convert(inputPath string, unmarshal unmarshaller, marshal marshaller) ([]byte, error) {
+	object, err := unmarshalPath(inputPath, unmarshal)
+	if err != nil {
+		return nil, err
+	}
+
+	outputContent, err2 := render(object, marshal)
+	if err2 != nil {
+		return nil, err2
+	}
+	if outputContent == nil {
+		return nil, emptyResult()
+	}
+	return outputContent, nil
+}
+
+// convertDocuments converts the input like convert, or every entry of the
+// input archive with --input-tar like a separate input.
+func convertDocuments(inputPath string, unmarshal unmarshaller, marshal marshaller) ([][]byte, error) {
+	if !inputTar {
+		document, err := convert(inputPath, unmarshal, marshal)
+		if err != nil || document == nil {
+			return nil, err
+		}
+		return [][]byte{document}, nil
+	}
+	objects, err := unmarshalTarEntries(inputPath, unmarshal)
+	if err != nil {
+		return nil, err
+	}
+	progress.fileDone()
+	if len(objects) == 0 {
+		return nil, emptyResult()
+	}
+	var documents [][]byte
+	for _, object := range objects {
+		document, err := render(object, marshal)
+		if err != nil {
+			return nil, err
+		}
+		if document == nil {
+			if err := emptyResult(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		documents = append(documents, document)
+	}
+	return documents, nil
+}
+
+// unmarshalPath decodes the input, or the entries of the input archive with --input-tar.
+func unmarshalPath(inputPath string, unmarshal unmarshaller) (interface{}, error) {
+	var object interface{}
+	var err error
+	if inputTar {
+		object, err = unmarshalTar(inputPath, unmarshal)
+	} else {
+		object, err = unmarshalInput(inputPath, unmarshal)
+	}
+	if err == nil {
+		progress.fileDone()
+	}
+	return object, err
+}
+
+// unmarshalInput reads, decodes and validates a single input.
+func unmarshalInput(inputPath string, unmarshal unmarshaller) (interface{}, error) {
+	inputContent, err := readInput(inputPath)
+	if err != nil {
+		return nil, err
+	}
+	if rawInput {
+		return string(inputContent), nil
+	}
 
 	logrus.Debug("Unmarshal to an object")
 	object, err1 := unmarshal(inputContent)
 	if err1 != nil {
-		return err1
+		return nil, fmt.Errorf("cannot parse %s: %v", inputName(inputPath), err1)
+	}
+	if err := checkDepth(inputPath, object); err != nil {
+		return nil, err
+	}
+	if err := validateSchema(inputPath, object); err != nil {
+		return nil, err
 	}
+	return applyPatch(object)
+}
+
+// query selects the part of the object to output,
+// with the jq expression or the JSONPath templates.
+func query(object interface{}) (interface{}, error) {
 	if object == nil {
-		return writeOutput([]byte{})
+		return nil, nil
+	}
+	object, err := filterWhere(object)
+	if err != nil {
+		return nil, err
+	}
+	if object, err = filterFieldSelector(object); err != nil {
+		return nil, err
+	}
+	if jqCode != nil {
+		return runJQ(jqCode, jqExpression, object)
+	}
+	if selectSegments != nil {
+		return selectValue(object, selectSegments)
 	}
+	return filterAll(object, jsonpathTemplates)
+}
 
-	resultObject, err2 := filter(object, jsonpathTemplate)
-	if err2 != nil {
-		return err2
+// resultCount is the number of values selected by query.
+func resultCount(resultObject interface{}) int {
+	if resultObject == nil {
+		return 0
+	}
+	if results, ok := resultObject.(jsonpathResults); ok {
+		return len(results)
+	}
+	return 1
+}
+
+// render filters and marshals a decoded object,
+// it returns nil when there is nothing to output.
+func render(object interface{}, marshal marshaller) ([]byte, error) {
+	defer forgetKeyOrder(object)
+	object, err := applyPipe(object)
+	if err != nil {
+		return nil, err
+	}
+	if object, err = applySets(object); err != nil {
+		return nil, err
+	}
+	if normalizeQuantities {
+		addQuantityValues(object)
+	}
+	resultObject, err := query(object)
+	if err != nil {
+		return nil, err
+	}
+
+	if countResults {
+		return []byte(strconv.Itoa(resultCount(resultObject))), nil
 	}
 
 	if resultObject == nil {
 		logrus.Debug("No results found for the JSON Path")
-		return writeOutput([]byte{})
+		return nil, nil
+	}
+
+	shapeObject(resultObject)
+	stripObject(resultObject)
+	resultObject = wrapObject(resultObject)
+
+	if sortKeys {
+		sortObjectKeys(resultObject)
+	}
+
+	if withTypes {
+		resultObject = annotateTypes(resultObject)
+	}
+
+	if outputTemplate != nil {
+		return executeTemplate(resultObject)
+	}
+
+	if decodeBase64 || encodeBase64 {
+		return convertBase64(resultObject, marshal)
+	}
+
+	if results, ok := resultObject.(jsonpathResults); ok {
+		if raw {
+			return marshalEach(results, marshal)
+		}
+		resultObject = []interface{}(results)
+	}
+	if s, ok := resultObject.(string); ok && raw {
+		return []byte(s), nil
 	}
 
 	logrus.Debug("Marshal to an object")
-	outputContent, err3 := marshal(resultObject)
-	if err3 != nil {
-		return err3
+	outputContent, err := marshal(resultObject)
+	if err != nil {
+		return nil, err
+	}
+	if verifyOutput {
+		if err := verifyDocument(resultObject, outputContent); err != nil {
+			return nil, err
+		}
 	}
 	logrus.Debugf("Output: %v", string(outputContent))
-	return writeOutput(outputContent)
-}
\ No newline at end of file
+	return outputContent, nil
+}
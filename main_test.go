@@ -0,0 +1,212 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// convertText decodes the input with the from format, and filters and
+// marshals it with the to format like a conversion does.
+func convertText(from, to format, input string) (string, error) {
+	object, err := from.unmarshal([]byte(input))
+	if err != nil {
+		return "", err
+	}
+	output, err := render(object, to.marshal)
+	return string(output), err
+}
+
+func TestUnmarshalYAML(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected interface{}
+	}{
+		{"empty", "", nil},
+		{"comment only", "# nothing\n", nil},
+		{"single document", "a: 1\n", map[string]interface{}{"a": float64(1)}},
+		{"leading marker", "---\na: 1\n", map[string]interface{}{"a": float64(1)}},
+		{"documents", "a: 1\n---\nb: 2\n", []interface{}{
+			map[string]interface{}{"a": float64(1)},
+			map[string]interface{}{"b": float64(2)},
+		}},
+		{"empty documents skipped", "---\n---\na: 1\n---\n\n---\nb: 2\n", []interface{}{
+			map[string]interface{}{"a": float64(1)},
+			map[string]interface{}{"b": float64(2)},
+		}},
+		{"content after the marker", "--- a\n--- b\n", []interface{}{"a", "b"}},
+		{"marker in a string", "a: |\n  ---x\n", map[string]interface{}{"a": "---x\n"}},
+		{"null document", "a: 1\n---\n~\n", map[string]interface{}{"a": float64(1)}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			object, err := unmarshalYAML([]byte(test.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(object, test.expected) {
+				t.Errorf("expected %#v, got %#v", test.expected, object)
+			}
+		})
+	}
+}
+
+func TestYAMLDocumentsToJSON(t *testing.T) {
+	output, err := convertText(yamlFormat, jsonFormat, "name: a\n---\nname: b\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `[{"name":"a"},{"name":"b"}]`; output != expected {
+		t.Errorf("expected %s, got %s", expected, output)
+	}
+}
+
+func TestWriteOutputMode(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing os.FileMode
+		expected os.FileMode
+	}{
+		{"new file", 0, 0644},
+		{"private file", 0600, 0600},
+		{"executable file", 0755, 0755},
+		{"group readable file", 0640, 0640},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "output.json")
+			if test.existing != 0 {
+				if err := ioutil.WriteFile(path, []byte("old"), test.existing); err != nil {
+					t.Fatal(err)
+				}
+				// the umask may have dropped some of the bits
+				if err := os.Chmod(path, test.existing); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if mode := outputMode(path); mode != test.expected {
+				t.Errorf("expected the mode %v, got %v", test.expected, mode)
+			}
+			if err := writeOutput(path, []byte(`{"a":1}`)); err != nil {
+				t.Fatal(err)
+			}
+			content, err := ioutil.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(content) != `{"a":1}` {
+				t.Errorf("unexpected content %q", content)
+			}
+			if test.existing == 0 {
+				return
+			}
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Mode().Perm() != test.expected {
+				t.Errorf("expected the mode %v, got %v", test.expected, info.Mode().Perm())
+			}
+		})
+	}
+}
+
+func TestCountResults(t *testing.T) {
+	tests := []struct {
+		name     string
+		jsonpath string
+		input    string
+		expected string
+	}{
+		{"document", "", `{"a":1}`, "1"},
+		{"empty document", "", ``, "0"},
+		{"scalar", "{.a}", `{"a":1}`, "1"},
+		{"array", "{.items}", `{"items":[1,2,3]}`, "1"},
+		{"matches", "{.items[*]}", `{"items":[1,2,3]}`, "3"},
+		{"no matches", "{.items[*]}", `{"items":[]}`, "0"},
+	}
+	defer func(templates []string, count bool) {
+		jsonpathTemplates, countResults = templates, count
+	}(jsonpathTemplates, countResults)
+	countResults = true
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			jsonpathTemplates = nil
+			if test.jsonpath != "" {
+				jsonpathTemplates = []string{test.jsonpath}
+			}
+			output, err := convertText(jsonFormat, jsonFormat, test.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if output != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, output)
+			}
+		})
+	}
+}
+
+func TestMarshalNDJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"array", "- name: a\n- name: b\n", "{\"name\":\"a\"}\n{\"name\":\"b\"}"},
+		{"scalars", "- 1\n- two\n- null\n", "1\n\"two\"\nnull"},
+		{"nested arrays", "- [1, 2]\n- []\n", "[1,2]\n[]"},
+		{"empty array", "[]\n", ""},
+		{"object", "name: a\nlist: [1]\n", `{"list":[1],"name":"a"}`},
+		{"multiline strings", "- \"a\\nb\"\n", `"a\nb"`},
+	}
+	defer func(enabled bool) { ndjson = enabled }(ndjson)
+	ndjson = true
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output, err := convertText(yamlFormat, jsonFormat, test.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if output != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, output)
+			}
+		})
+	}
+}
+
+func TestRawOutput(t *testing.T) {
+	input := `{"name":"web","replicas":3,"ratio":0.5,"ready":true,"labels":{"app":"web"},"ports":[80,443],"note":"two\nlines","items":["a",{"b":1},[2]]}`
+	tests := []struct {
+		name     string
+		raw      bool
+		jsonpath string
+		expected string
+	}{
+		{"string", true, "{.name}", "web"},
+		{"quoted string", false, "{.name}", `"web"`},
+		{"multiline string", true, "{.note}", "two\nlines"},
+		{"integer", true, "{.replicas}", "3"},
+		{"float", true, "{.ratio}", "0.5"},
+		{"boolean", true, "{.ready}", "true"},
+		{"object", true, "{.labels}", `{"app":"web"}`},
+		{"array", true, "{.ports}", "[80,443]"},
+		{"several results", true, "{.items[*]}", "a\n{\"b\":1}\n[2]"},
+		{"several results without raw", false, "{.items[*]}", `["a",{"b":1},[2]]`},
+	}
+	defer func(templates []string, enabled bool) { jsonpathTemplates, raw = templates, enabled }(jsonpathTemplates, raw)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			jsonpathTemplates, raw = []string{test.jsonpath}, test.raw
+			output, err := convertText(jsonFormat, jsonFormat, input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if output != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, output)
+			}
+		})
+	}
+}
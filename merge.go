@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"github.com/urfave/cli"
+	"sort"
+	"strings"
+)
+
+var arrayMerge string
+
+var mergeFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:        "to",
+		Usage:       "the output format",
+		Value:       "json",
+		Destination: &toFormat,
+	},
+	cli.StringFlag{
+		Name:        "array-merge",
+		Usage:       "how the arrays are merged, 'replace' or 'concat'",
+		Value:       "replace",
+		Destination: &arrayMerge,
+	},
+}
+
+// merge deep-merges the YAML or JSON inputs in order and writes the result.
+func merge(to format) error {
+	if arrayMerge != "replace" && arrayMerge != "concat" {
+		return usageError(fmt.Sprintf("invalid --array-merge %q, expected 'replace' or 'concat'", arrayMerge))
+	}
+	if err := prepareFilters(); err != nil {
+		return err
+	}
+	if err := setupVerify(to); err != nil {
+		return err
+	}
+	paths, err := expandInputs(inputPaths)
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		// no input paths, use stdin
+		paths = []string{""}
+	}
+
+	var merged interface{}
+	for _, path := range paths {
+		content, err := readInput(path)
+		if err != nil {
+			return err
+		}
+		object, err := unmarshalYAML(content)
+		if err != nil {
+			return fmt.Errorf("cannot parse %s: %v", inputName(path), err)
+		}
+		if err := checkDepth(path, object); err != nil {
+			return err
+		}
+		if err := validateSchema(path, object); err != nil {
+			return err
+		}
+		if merged, err = mergeValues(merged, object, nil); err != nil {
+			return fmt.Errorf("cannot merge %s: %v", inputName(path), err)
+		}
+	}
+
+	output, err := render(merged, to.marshal)
+	if err != nil {
+		return err
+	}
+	if output == nil {
+		return emptyResult()
+	}
+	return writeOutput(outputPath, output)
+}
+
+// mergeValues merges the overriding value into the base one. Objects are merged
+// key by key, arrays per --array-merge, and any other value replaces the base.
+func mergeValues(base, override interface{}, path []string) (interface{}, error) {
+	if base == nil || override == nil {
+		if override == nil {
+			return base, nil
+		}
+		return override, nil
+	}
+	switch o := override.(type) {
+	case map[string]interface{}:
+		b, ok := base.(map[string]interface{})
+		if !ok {
+			return nil, mergeConflict(path, base, override)
+		}
+		keys := make([]string, 0, len(o))
+		for key := range o {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			value, err := mergeValues(b[key], o[key], append(path, key))
+			if err != nil {
+				return nil, err
+			}
+			b[key] = value
+		}
+		return b, nil
+	case []interface{}:
+		b, ok := base.([]interface{})
+		if !ok {
+			return nil, mergeConflict(path, base, override)
+		}
+		if arrayMerge == "concat" {
+			// a new array, the base one may share its backing array
+			merged := make([]interface{}, 0, len(b)+len(o))
+			return append(append(merged, b...), o...), nil
+		}
+		return o, nil
+	default:
+		switch base.(type) {
+		case map[string]interface{}, []interface{}:
+			return nil, mergeConflict(path, base, override)
+		}
+		return o, nil
+	}
+}
+
+func mergeConflict(path []string, base, override interface{}) error {
+	key := "the document"
+	if len(path) > 0 {
+		key = fmt.Sprintf("%q", strings.Join(path, "."))
+	}
+	return fmt.Errorf("%s is %s in one input and %s in another", key, jsonKind(base), jsonKind(override))
+}
+
+// jsonKind names the kind of a decoded value for the error messages.
+func jsonKind(value interface{}) string {
+	switch value.(type) {
+	case map[string]interface{}:
+		return "an object"
+	case []interface{}:
+		return "an array"
+	case string:
+		return "a string"
+	case float64, int:
+		return "a number"
+	case bool:
+		return "a boolean"
+	default:
+		return "a scalar"
+	}
+}
+
+var (
+	patchPath    string
+	patchContent []byte
+)
+
+// loadPatch reads the --patch, a path or - for stdin.
+func loadPatch() error {
+	if patchPath == "" {
+		return nil
+	}
+	path := patchPath
+	if path == "-" {
+		if len(inputPaths) == 0 {
+			return usageError("--patch - requires an input file, stdin cannot be both the input and the patch")
+		}
+		path = ""
+	}
+	content, err := readInput(path)
+	if err != nil {
+		return err
+	}
+	patchContent = content
+	return nil
+}
+
+// applyPatch deep-merges the --patch onto the decoded input, like the merge command.
+func applyPatch(object interface{}) (interface{}, error) {
+	if patchContent == nil {
+		return object, nil
+	}
+	// decoded for every input, so that the merged objects share nothing
+	patch, err := unmarshalYAML(patchContent)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse the patch %s: %v", patchPath, err)
+	}
+	merged, err := mergeValues(object, patch, nil)
+	if err != nil {
+		return nil, fmt.Errorf("cannot apply the patch %s: %v", patchPath, err)
+	}
+	return merged, nil
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"github.com/vmihailenco/msgpack/v5"
+	"io"
+	"reflect"
+	"time"
+)
+
+var msgpackFormat = format{unmarshal: unmarshalMsgpack, marshal: marshalMsgpack}
+
+// unmarshalMsgpack decodes the MessagePack values of the input, several
+// concatenated values being handled like the documents of a YAML stream.
+// The maps are decoded with keys of any type, which msgpackToJSON turns
+// into strings, and the input ending within a value is an error.
+func unmarshalMsgpack(input []byte) (interface{}, error) {
+	var objects []interface{}
+	reader := bytes.NewReader(input)
+	decoder := msgpack.NewDecoder(reader)
+	decoder.SetMapDecoder(func(d *msgpack.Decoder) (interface{}, error) {
+		return d.DecodeUntypedMap()
+	})
+	for reader.Len() > 0 {
+		value, err := decoder.DecodeInterface()
+		if err == io.EOF {
+			return nil, io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return nil, err
+		}
+		object, err := msgpackToJSON(value)
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, object)
+	}
+
+	if len(objects) == 0 {
+		return nil, nil
+	} else if len(objects) == 1 {
+		return objects[0], nil
+	} else {
+		return objects, nil
+	}
+}
+
+// msgpackToJSON converts the decoded MessagePack into the same structure the
+// JSON decoder produces. The binary values become base64 strings, and the
+// extension types, which have no JSON counterpart, are an error. The integers
+// stay integers with --preserve-int.
+func msgpackToJSON(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case nil, bool, string, float64:
+		return v, nil
+	case int8, int16, int32, int64:
+		if preserveInt {
+			return preservedInt(reflect.ValueOf(v).Int()), nil
+		}
+		return float64(reflect.ValueOf(v).Int()), nil
+	case uint8, uint16, uint32, uint64:
+		if preserveInt {
+			return preservedInt(reflect.ValueOf(v).Uint()), nil
+		}
+		return float64(reflect.ValueOf(v).Uint()), nil
+	case float32:
+		return float64(v), nil
+	case []byte:
+		return base64.StdEncoding.EncodeToString(v), nil
+	case time.Time:
+		return v.Format(time.RFC3339Nano), nil
+	case map[string]interface{}:
+		for key, item := range v {
+			converted, err := msgpackToJSON(item)
+			if err != nil {
+				return nil, err
+			}
+			v[key] = converted
+		}
+		return v, nil
+	case map[interface{}]interface{}:
+		object := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			converted, err := msgpackToJSON(item)
+			if err != nil {
+				return nil, err
+			}
+			object[fmt.Sprint(key)] = converted
+		}
+		return object, nil
+	case []interface{}:
+		for i, item := range v {
+			converted, err := msgpackToJSON(item)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = converted
+		}
+		return v, nil
+	default:
+		return nil, fmt.Errorf("unsupported MessagePack value of type %T", value)
+	}
+}
+
+// marshalMsgpack encodes the object as MessagePack, the whole numbers
+// as integers like the TOML output does.
+func marshalMsgpack(object interface{}) ([]byte, error) {
+	var output bytes.Buffer
+	encoder := msgpack.NewEncoder(&output)
+	encoder.UseCompactInts(true)
+	if err := encoder.Encode(jsonToTOML(object)); err != nil {
+		return nil, err
+	}
+	return output.Bytes(), nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import "testing"
+
+func TestUnmarshalMsgpack(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"fixmap with integer keys", "\x82\x01\xa1a\x02\xc3", `{"1":"a","2":true}`},
+		{"map16 with boolean keys", "\xde\x00\x02\xc3\x01\xc2\x02", `{"false":2,"true":1}`},
+		{"map16 with string keys", "\xde\x00\x01\xa4name\xa32fy", `{"name":"2fy"}`},
+		{"nested arrays", "\x92\x92\x01\x02\x90", `[[1,2],[]]`},
+		{"array in a map", "\x81\xa5items\x92\x93\x01\x02\x03\xc0", `{"items":[[1,2,3],null]}`},
+		{"map in an array", "\x91\x81\xa1a\xcb\x3f\xf8\x00\x00\x00\x00\x00\x00", `[{"a":1.5}]`},
+		{"binary", "\xc4\x02hi", `"aGk="`},
+		{"values", "\x01\xa3two", `[1,"two"]`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output, err := convertText(msgpackFormat, jsonFormat, test.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if output != test.expected {
+				t.Errorf("expected %s, got %s", test.expected, output)
+			}
+		})
+	}
+}
+
+func TestUnmarshalMsgpackErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"ext type", "\xd4\x05\x00"},
+		{"ext type in a map", "\x81\xa1a\xd5\x07\x00\x01"},
+		{"ext type in an array", "\x92\x01\xc7\x01\x2a\x00"},
+		{"truncated", "\x92\x01"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := unmarshalMsgpack([]byte(test.input)); err == nil {
+				t.Errorf("expected an error for %q", test.input)
+			}
+		})
+	}
+}
+
+func TestMsgpackRoundTrip(t *testing.T) {
+	for _, input := range []string{`{"a":[1,2.5,"x"],"b":{"c":null,"d":true}}`, `[[],{}]`, `-3`} {
+		t.Run(input, func(t *testing.T) {
+			encoded, err := convertText(jsonFormat, msgpackFormat, input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			output, err := convertText(msgpackFormat, jsonFormat, encoded)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if output != input {
+				t.Errorf("expected %s, got %s", input, output)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// trailingNewline is "true" or "false" with --trailing-newline,
+// empty to keep the trailing newline of the output format.
+var trailingNewline string
+
+func checkTrailingNewline() error {
+	switch trailingNewline {
+	case "", "true", "false":
+		return nil
+	}
+	return usageError(fmt.Sprintf("invalid --trailing-newline %q, expected true or false", trailingNewline))
+}
+
+// withTrailingNewline makes the content end with exactly one newline,
+// or none, per --trailing-newline.
+func withTrailingNewline(content []byte) []byte {
+	if trailingNewline == "" || len(content) == 0 {
+		return content
+	}
+	content = bytes.TrimRight(content, "\n")
+	if trailingNewline == "true" {
+		content = append(content, '\n')
+	}
+	return content
+}
+
+// newlineWriter applies --trailing-newline to a streamed output by holding
+// the newlines back until more content follows them or the stream ends.
+type newlineWriter struct {
+	writer   io.Writer
+	newlines int
+	written  bool
+}
+
+func (w *newlineWriter) Write(p []byte) (int, error) {
+	content := bytes.TrimRight(p, "\n")
+	if len(content) > 0 {
+		if _, err := w.writer.Write(bytes.Repeat([]byte("\n"), w.newlines)); err != nil {
+			return 0, err
+		}
+		if _, err := w.writer.Write(content); err != nil {
+			return 0, err
+		}
+		w.newlines = 0
+		w.written = true
+	}
+	w.newlines += len(p) - len(content)
+	return len(p), nil
+}
+
+// Close writes the newlines held back, or the single one or none of --trailing-newline.
+func (w *newlineWriter) Close() error {
+	if trailingNewline == "" {
+		_, err := w.writer.Write(bytes.Repeat([]byte("\n"), w.newlines))
+		return err
+	}
+	if trailingNewline == "true" && w.written {
+		_, err := w.writer.Write([]byte("\n"))
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/ghodss/yaml"
+	"io"
+	"math"
+	"strconv"
+)
+
+var preserveInt bool
+
+// newJSONDecoder is json.NewDecoder, decoding the numbers as json.Number
+// with --preserve-int so that preserveNumbers can tell the integers apart.
+func newJSONDecoder(r io.Reader) *json.Decoder {
+	decoder := json.NewDecoder(r)
+	if preserveInt {
+		decoder.UseNumber()
+	}
+	return decoder
+}
+
+// decodeJSON is json.Unmarshal, keeping the integers with --preserve-int.
+func decodeJSON(input []byte, object *interface{}) error {
+	if !preserveInt {
+		return json.Unmarshal(input, object)
+	}
+	decoder := newJSONDecoder(bytes.NewReader(input))
+	if err := decoder.Decode(object); err != nil {
+		return err
+	}
+	if rest := bytes.TrimSpace(input[decoder.InputOffset():]); len(rest) > 0 {
+		// let json.Unmarshal report the data after the value
+		return json.Unmarshal(input, new(json.RawMessage))
+	}
+	*object = preserveNumbers(*object)
+	return nil
+}
+
+// decodeYAML is yaml.Unmarshal, keeping the integers with --preserve-int.
+// The YAML decoder reads the integers exactly, they are only lost
+// when the JSON it is converted to is decoded into float64.
+func decodeYAML(document []byte, object *interface{}) error {
+	if !preserveInt {
+		return yaml.Unmarshal(document, object)
+	}
+	encoded, err := yaml.YAMLToJSON(document)
+	if err != nil {
+		return err
+	}
+	return decodeJSON(encoded, object)
+}
+
+// preserveNumbers replaces the json.Number values with an int when they are
+// integers that fit in one, and with a float64 otherwise. An int is what jq
+// works with, and what the encoders write without a fraction or an exponent.
+func preserveNumbers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if number, err := strconv.ParseInt(string(v), 10, 0); err == nil {
+			return int(number)
+		}
+		number, _ := strconv.ParseFloat(string(v), 64)
+		return number
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = preserveNumbers(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = preserveNumbers(item)
+		}
+	}
+	return value
+}
+
+// preservedInt converts an integer decoded by yaml.v2 like preserveNumbers does.
+func preservedInt(value interface{}) interface{} {
+	switch v := value.(type) {
+	case int:
+		return v
+	case int64:
+		if v >= math.MinInt && v <= math.MaxInt {
+			return int(v)
+		}
+		return float64(v)
+	case uint64:
+		if v <= math.MaxInt {
+			return int(v)
+		}
+		return float64(v)
+	}
+	return value
+}
+
+// jsonNumber returns the value as a float64 when it is a number, so that
+// the numbers decoded with --preserve-int compare like the other ones.
+func jsonNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	}
+	return 0, false
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/fxamacker/cbor/v2"
+	"github.com/vmihailenco/msgpack/v5"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestPreserveInt(t *testing.T) {
+	msgpackInput, err := msgpack.Marshal(map[string]interface{}{"big": uint64(9007199254740993), "negative": int8(-7), "ratio": 1.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cborInput, err := cbor.Marshal(map[string]interface{}{"big": uint64(9007199254740993), "negative": int64(-7), "ratio": 1.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name          string
+		from          format
+		preserveOrder bool
+		input         string
+		preserved     string
+		rounded       string
+	}{
+		{"json", jsonFormat, false, `{"big":9007199254740993,"million":1000000,"whole":3.0,"ratio":1.5,"huge":1e20}`,
+			`{"big":9007199254740993,"huge":100000000000000000000,"million":1000000,"ratio":1.5,"whole":3}`,
+			`{"big":9007199254740992,"huge":100000000000000000000,"million":1000000,"ratio":1.5,"whole":3}`},
+		{"json array", jsonFormat, false, `[9007199254740993,-1]`, `[9007199254740993,-1]`, `[9007199254740992,-1]`},
+		{"yaml", yamlFormat, false, "big: 9007199254740993\nmillion: 1000000\nratio: 1.5\n",
+			`{"big":9007199254740993,"million":1000000,"ratio":1.5}`,
+			`{"big":9007199254740992,"million":1000000,"ratio":1.5}`},
+		{"ordered yaml", yamlFormat, true, "million: 1000000\nbig: 9007199254740993\n",
+			`{"million":1000000,"big":9007199254740993}`,
+			`{"million":1000000,"big":9007199254740992}`},
+		{"msgpack", msgpackFormat, false, string(msgpackInput),
+			`{"big":9007199254740993,"negative":-7,"ratio":1.5}`,
+			`{"big":9007199254740992,"negative":-7,"ratio":1.5}`},
+		{"edn", ednFormat, false, "{:big 9007199254740993 :bignum 9007199254740995N :ratio 1.5}",
+			`{"big":9007199254740993,"bignum":9007199254740995,"ratio":1.5}`,
+			`{"big":9007199254740992,"bignum":9007199254740996,"ratio":1.5}`},
+		{"toml", tomlFormat, false, "big = 9007199254740993\nratio = 1.5\n",
+			`{"big":9007199254740993,"ratio":1.5}`,
+			`{"big":9007199254740992,"ratio":1.5}`},
+		{"cbor", cborFormat, false, string(cborInput),
+			`{"big":9007199254740993,"negative":-7,"ratio":1.5}`,
+			`{"big":9007199254740992,"negative":-7,"ratio":1.5}`},
+	}
+	defer func(preserve, order bool) { preserveInt, preserveOrder = preserve, order }(preserveInt, preserveOrder)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			preserveOrder = test.preserveOrder
+			for _, preserve := range []bool{true, false} {
+				preserveInt = preserve
+				output, err := convertText(test.from, jsonFormat, test.input)
+				if err != nil {
+					t.Fatal(err)
+				}
+				expected := test.rounded
+				if preserve {
+					expected = test.preserved
+				}
+				if output != expected {
+					t.Errorf("expected %s with --preserve-int=%v, got %s", expected, preserve, output)
+				}
+			}
+		})
+	}
+}
+
+func TestPreserveIntErrors(t *testing.T) {
+	defer func(preserve bool) { preserveInt = preserve }(preserveInt)
+	preserveInt = true
+	for _, input := range []string{`{"a":1} x`, `{"a":}`, `[1,]`} {
+		if _, err := unmarshalJSON([]byte(input)); err == nil {
+			t.Errorf("expected an error for %s", input)
+		}
+	}
+}
+
+func TestPreserveNumbers(t *testing.T) {
+	tests := []struct {
+		number   json.Number
+		expected interface{}
+	}{
+		{"0", 0},
+		{"-12", -12},
+		{"9223372036854775807", math.MaxInt64},
+		{"9223372036854775808", 9223372036854775808.0},
+		{"1.0", 1.0},
+		{"1e3", 1000.0},
+	}
+	for _, test := range tests {
+		t.Run(string(test.number), func(t *testing.T) {
+			if value := preserveNumbers(test.number); !reflect.DeepEqual(value, test.expected) {
+				t.Errorf("expected %#v, got %#v", test.expected, value)
+			}
+		})
+	}
+}
+
+func TestPreservedInt(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    interface{}
+		expected interface{}
+	}{
+		{"int", 3, 3},
+		{"int64", int64(-3), -3},
+		{"uint64", uint64(3), 3},
+		{"uint64 beyond int", uint64(math.MaxUint64), float64(math.MaxUint64)},
+		{"float64", 1.5, 1.5},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if value := preservedInt(test.value); !reflect.DeepEqual(value, test.expected) {
+				t.Errorf("expected %#v, got %#v", test.expected, value)
+			}
+		})
+	}
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/urfave/cli"
+	yamlv2 "gopkg.in/yaml.v2"
+	"io"
+	"reflect"
+	"sort"
+)
+
+var preserveOrder bool
+
+var preserveOrderFlag = cli.BoolFlag{
+	Name:        "preserve-order",
+	Usage:       "keep the key order of the YAML mappings in the JSON output",
+	Destination: &preserveOrder,
+}
+
+var sortKeys bool
+
+// keyOrder remembers the key order of the maps decoded with --preserve-order.
+// It is indexed by map identity, so that it survives the JSONPath filtering,
+// which returns the very same maps. Every entry holds on to its map, so that
+// a live map cannot be freed and its address reused by a new map, and the
+// entries of a document are dropped by forgetKeyOrder once it is rendered.
+var keyOrder = map[uintptr]recordedOrder{}
+
+type recordedOrder struct {
+	object map[string]interface{}
+	keys   []string
+}
+
+// recordKeyOrder remembers the keys of the map in their order.
+func recordKeyOrder(object map[string]interface{}, keys []string) {
+	keyOrder[reflect.ValueOf(object).Pointer()] = recordedOrder{object: object, keys: keys}
+}
+
+// recordedKeys returns the keys recorded for the map, if any.
+func recordedKeys(object map[string]interface{}) []string {
+	return keyOrder[reflect.ValueOf(object).Pointer()].keys
+}
+
+// forgetKeyOrder drops the recorded key order of every map of the value,
+// which lets the rendered documents be freed.
+func forgetKeyOrder(value interface{}) {
+	if len(keyOrder) == 0 {
+		return
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		delete(keyOrder, reflect.ValueOf(v).Pointer())
+		for _, item := range v {
+			forgetKeyOrder(item)
+		}
+	case []interface{}:
+		for _, item := range v {
+			forgetKeyOrder(item)
+		}
+	case jsonpathResults:
+		for _, item := range v {
+			forgetKeyOrder(item)
+		}
+	}
+}
+
+// orderedValue decodes any YAML node, decoding the mappings into
+// yaml.MapSlice so that the order of their keys is kept.
+type orderedValue struct {
+	value interface{}
+}
+
+func (v *orderedValue) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var probe interface{}
+	if err := unmarshal(&probe); err != nil {
+		return err
+	}
+	switch probe.(type) {
+	case map[interface{}]interface{}:
+		var mapping yamlv2.MapSlice
+		if err := unmarshal(&mapping); err != nil {
+			return err
+		}
+		v.value = mapping
+	case []interface{}:
+		var sequence []orderedValue
+		if err := unmarshal(&sequence); err != nil {
+			return err
+		}
+		v.value = sequence
+	default:
+		v.value = probe
+	}
+	return nil
+}
+
+// unmarshalOrderedYAML decodes the documents like unmarshalYAML,
+// recording the key order of every mapping.
+func unmarshalOrderedYAML(input []byte) (interface{}, error) {
+	var objects []interface{}
+	reader := newYAMLReader(bytes.NewReader(input))
+	for {
+		document, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := checkStrictYAML(document); err != nil {
+			return nil, yamlError(err, reader.start)
+		}
+		var object interface{}
+		if err := decodeOrderedYAML(document, &object); err != nil {
+			return nil, yamlError(err, reader.start)
+		}
+		if object != nil {
+			objects = append(objects, object)
+		}
+	}
+
+	yamlStreamDocuments = len(objects)
+	if len(objects) == 0 {
+		return nil, nil
+	} else if len(objects) == 1 {
+		return objects[0], nil
+	} else {
+		return objects, nil
+	}
+}
+
+// decodeOrderedYAML decodes a single document like decodeYAML,
+// recording the key order of every mapping.
+func decodeOrderedYAML(document []byte, object *interface{}) error {
+	var value orderedValue
+	if err := yamlv2.Unmarshal(document, &value); err != nil {
+		return err
+	}
+	*object = toOrderedObject(value.value)
+	return nil
+}
+
+// toOrderedObject converts the decoded YAML into the same structure
+// the JSON decoder produces, recording the key order on the way.
+func toOrderedObject(value interface{}) interface{} {
+	switch v := value.(type) {
+	case yamlv2.MapSlice:
+		object := make(map[string]interface{}, len(v))
+		keys := make([]string, 0, len(v))
+		for _, item := range v {
+			key := fmt.Sprint(item.Key)
+			if _, ok := object[key]; !ok {
+				keys = append(keys, key)
+			}
+			object[key] = toOrderedObject(item.Value)
+		}
+		recordKeyOrder(object, keys)
+		return object
+	case []orderedValue:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = toOrderedObject(item.value)
+		}
+		return items
+	case []interface{}:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = toOrderedObject(item)
+		}
+		return items
+	case map[interface{}]interface{}:
+		object := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			object[fmt.Sprint(key)] = toOrderedObject(item)
+		}
+		return object
+	case int:
+		if preserveInt {
+			return v
+		}
+		return float64(v)
+	case int64:
+		if preserveInt {
+			return preservedInt(v)
+		}
+		return float64(v)
+	case uint64:
+		if preserveInt {
+			return preservedInt(v)
+		}
+		return float64(v)
+	default:
+		return v
+	}
+}
+
+// orderedKeys returns the keys of the map in their recorded order,
+// followed by the keys added since then in alphabetical order.
+func orderedKeys(object map[string]interface{}) []string {
+	var keys []string
+	seen := map[string]bool{}
+	for _, key := range recordedKeys(object) {
+		if _, ok := object[key]; ok {
+			keys = append(keys, key)
+			seen[key] = true
+		}
+	}
+	var added []string
+	for key := range object {
+		if !seen[key] {
+			added = append(added, key)
+		}
+	}
+	sort.Strings(added)
+	return append(keys, added...)
+}
+
+// marshalOrderedJSON writes compact JSON with the keys of every map in their recorded order.
+func marshalOrderedJSON(object interface{}) ([]byte, error) {
+	var output bytes.Buffer
+	if err := writeOrderedJSON(&output, object); err != nil {
+		return nil, err
+	}
+	return output.Bytes(), nil
+}
+
+func writeOrderedJSON(output *bytes.Buffer, value interface{}) error {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		output.WriteByte('{')
+		for i, key := range orderedKeys(v) {
+			if i > 0 {
+				output.WriteByte(',')
+			}
+			name, err := encodeJSON(key)
+			if err != nil {
+				return err
+			}
+			output.Write(name)
+			output.WriteByte(':')
+			if err := writeOrderedJSON(output, v[key]); err != nil {
+				return err
+			}
+		}
+		output.WriteByte('}')
+	case []interface{}:
+		output.WriteByte('[')
+		for i, item := range v {
+			if i > 0 {
+				output.WriteByte(',')
+			}
+			if err := writeOrderedJSON(output, item); err != nil {
+				return err
+			}
+		}
+		output.WriteByte(']')
+	default:
+		encoded, err := encodeJSON(v)
+		if err != nil {
+			return err
+		}
+		output.Write(encoded)
+	}
+	return nil
+}
+
+// sortObjectKeys forgets the recorded key order of every map of the value,
+// so that --sort-keys writes them in alphabetical order whatever the decoder.
+func sortObjectKeys(value interface{}) {
+	forgetKeyOrder(value)
+}
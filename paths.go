@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+var pathValues = true
+
+var pathsFormat = format{marshal: marshalPaths, separator: "\n"}
+
+// marshalPaths writes the dotted path of every leaf of the object, like
+// spec.containers[0].image, followed by " = " and its value with --values.
+// The strings are written as they are, unless empty or on several lines, and the
+// other values as JSON, the empty objects and arrays being leaves too.
+// The paths are those of --select, the keys with dots, brackets or spaces
+// being written in brackets, like spec['a.b'].
+func marshalPaths(object interface{}) ([]byte, error) {
+	var output bytes.Buffer
+	if err := writePaths(&output, object, ""); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(output.Bytes(), []byte("\n")), nil
+}
+
+func writePaths(output *bytes.Buffer, value interface{}, path string) error {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if len(v) > 0 {
+			for _, key := range orderedKeys(v) {
+				if err := writePaths(output, v[key], joinPathKey(path, key)); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	case []interface{}:
+		if len(v) > 0 {
+			for i, item := range v {
+				if err := writePaths(output, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+	if !pathValues {
+		if path != "" {
+			output.WriteString(path + "\n")
+		}
+		return nil
+	}
+	text, ok := value.(string)
+	if !ok || text == "" || strings.TrimSpace(text) != text || strings.ContainsAny(text, "\r\n") {
+		// the strings that would not read back as one line are quoted
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		text = string(encoded)
+	}
+	if path == "" {
+		// a scalar document
+		output.WriteString(text + "\n")
+		return nil
+	}
+	output.WriteString(path + " = " + text + "\n")
+	return nil
+}
+
+// joinPathKey appends the key to the path, after a dot or, when it would not
+// read back as one key, quoted in brackets.
+func joinPathKey(path, key string) string {
+	if key == "" || strings.ContainsAny(key, ".[]' \t\r\n") {
+		key = strings.Replace(strings.Replace(key, `\`, `\\`, -1), `'`, `\'`, -1)
+		return path + "['" + key + "']"
+	}
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMarshalPaths(t *testing.T) {
+	tests := []struct {
+		name     string
+		values   bool
+		input    string
+		expected string
+	}{
+		{"nested", true, `{"spec":{"containers":[{"image":"nginx","ports":[80,443]}]}}`,
+			"spec.containers[0].image = nginx\nspec.containers[0].ports[0] = 80\nspec.containers[0].ports[1] = 443"},
+		{"nested arrays", true, `{"a":[[1,2],[3]]}`, "a[0][0] = 1\na[0][1] = 2\na[1][0] = 3"},
+		{"root array", true, `[1,{"a":true}]`, "[0] = 1\n[1].a = true"},
+		{"scalar", true, `"text"`, "text"},
+		{"empty containers", true, `{"a":{},"b":[],"c":null}`, "a = {}\nb = []\nc = null"},
+		{"quoted strings", true, `{"a":"","b":" padded","c":"two\nlines","d":"one line"}`,
+			"a = \"\"\nb = \" padded\"\nc = \"two\\nlines\"\nd = one line"},
+		{"bracket keys", true, `{"spec":{"a.b":1,"my key":2,"it's":3,"":4,"x[0]":5}}`,
+			"spec[''] = 4\nspec['a.b'] = 1\nspec['it\\'s'] = 3\nspec['my key'] = 2\nspec['x[0]'] = 5"},
+		{"bracket root key", true, `{"my key":{"a":1}}`, "['my key'].a = 1"},
+		{"without values", false, `{"a":{"b":[1,{}]},"c":"x"}`, "a.b[0]\na.b[1]\nc"},
+		{"scalar without values", false, `1`, ""},
+	}
+	defer func(values bool) { pathValues = values }(pathValues)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pathValues = test.values
+			output, err := convertText(jsonFormat, pathsFormat, test.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if output != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, output)
+			}
+		})
+	}
+}
+
+func TestPathsSelectRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"plain keys", `{"spec":{"containers":[{"image":"nginx"}]}}`},
+		{"dotted keys", `{"metadata":{"annotations":{"app.kubernetes.io/name":"web"}}}`},
+		{"quotes and backslashes", `{"it's":{"a\\b":1}}`},
+		{"spaces and brackets", `{"my key":[{"x[0]":true}]}`},
+		{"empty key", `{"":{"a":"b"}}`},
+	}
+	defer func(values bool) { pathValues = values }(pathValues)
+	pathValues = false
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			object, err := jsonFormat.unmarshal([]byte(test.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			output, err := marshalPaths(object)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, path := range strings.Split(string(output), "\n") {
+				segments, err := parseSelectPath(path)
+				if err != nil {
+					t.Fatalf("cannot parse the path %q: %v", path, err)
+				}
+				if _, ok := lookupSegments(object, segments); !ok {
+					t.Errorf("the path %q does not select a value", path)
+				}
+			}
+		})
+	}
+}
+
+func TestParseSelectPathErrors(t *testing.T) {
+	for _, path := range []string{"a['b", "a['b'x]", "a[]", "a[x]", "a['']x"} {
+		t.Run(path, func(t *testing.T) {
+			if _, err := parseSelectPath(path); err == nil {
+				t.Errorf("expected an error for %q", path)
+			}
+		})
+	}
+}
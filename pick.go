@@ -0,0 +1,154 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	pickList string
+	omitList string
+	wrapKey  string
+)
+
+var (
+	pickPaths [][]string
+	omitPaths [][]string
+	wrapPath  []string
+)
+
+// parsePick parses the comma-separated dotted paths of --pick and --omit,
+// and the dotted path of --wrap-key.
+func parsePick() error {
+	if pickList != "" && omitList != "" {
+		return usageError("--pick and --omit cannot be combined")
+	}
+	var err error
+	if pickPaths, err = parseKeyPaths(pickList); err != nil {
+		return usageError(fmt.Sprintf("invalid --pick %q: %v", pickList, err))
+	}
+	if omitPaths, err = parseKeyPaths(omitList); err != nil {
+		return usageError(fmt.Sprintf("invalid --omit %q: %v", omitList, err))
+	}
+	wrapPath = nil
+	if strings.Contains(wrapKey, ",") {
+		return usageError(fmt.Sprintf("invalid --wrap-key %q, expected a single dotted path", wrapKey))
+	}
+	if paths, err := parseKeyPaths(wrapKey); err != nil {
+		return usageError(fmt.Sprintf("invalid --wrap-key %q: %v", wrapKey, err))
+	} else if paths != nil {
+		wrapPath = paths[0]
+	}
+	return nil
+}
+
+func parseKeyPaths(list string) ([][]string, error) {
+	if list == "" {
+		return nil, nil
+	}
+	var paths [][]string
+	for _, path := range strings.Split(list, ",") {
+		keys := strings.Split(strings.TrimSpace(path), ".")
+		for _, key := range keys {
+			if key == "" {
+				return nil, errors.New("empty key")
+			}
+		}
+		paths = append(paths, keys)
+	}
+	return paths, nil
+}
+
+// shapeObject applies --pick or --omit to the value, in place so that the
+// key order recorded with --preserve-order still applies. The paths go
+// through the arrays, applying to each of their elements.
+func shapeObject(value interface{}) {
+	if pickPaths != nil {
+		pickKeys(value, pickPaths)
+	} else if omitPaths != nil {
+		omitKeys(value, omitPaths)
+	}
+}
+
+// pickKeys removes every key of the objects of the value that is not on the paths,
+// and reports whether the value has keys at all, that is contains any object or array.
+func pickKeys(value interface{}, paths [][]string) bool {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			rest, whole := followKey(paths, key)
+			if whole {
+				continue
+			}
+			if len(rest) == 0 || !pickKeys(item, rest) {
+				delete(v, key)
+			}
+		}
+		return true
+	case []interface{}:
+		for _, item := range v {
+			pickKeys(item, paths)
+		}
+		return true
+	case jsonpathResults:
+		for _, item := range v {
+			pickKeys(item, paths)
+		}
+		return true
+	}
+	return false
+}
+
+// omitKeys removes every key on the paths from the objects of the value.
+func omitKeys(value interface{}, paths [][]string) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			rest, whole := followKey(paths, key)
+			if whole {
+				delete(v, key)
+			} else if len(rest) > 0 {
+				omitKeys(item, rest)
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			omitKeys(item, paths)
+		}
+	case jsonpathResults:
+		for _, item := range v {
+			omitKeys(item, paths)
+		}
+	}
+}
+
+// followKey returns the rest of the paths that go through the key,
+// and whether one of them ends at it.
+func followKey(paths [][]string, key string) ([][]string, bool) {
+	var rest [][]string
+	whole := false
+	for _, path := range paths {
+		if path[0] != key {
+			continue
+		}
+		if len(path) == 1 {
+			whole = true
+		} else {
+			rest = append(rest, path[1:])
+		}
+	}
+	return rest, whole
+}
+
+// wrapObject nests the value under the --wrap-key, the dotted keys
+// creating the intermediate objects.
+func wrapObject(value interface{}) interface{} {
+	if results, ok := value.(jsonpathResults); ok && wrapPath != nil {
+		value = []interface{}(results)
+	}
+	for i := len(wrapPath) - 1; i >= 0; i-- {
+		value = map[string]interface{}{wrapPath[i]: value}
+	}
+	return value
+}
@@ -0,0 +1,138 @@
+package main
+
+import "testing"
+
+func TestPickOmit(t *testing.T) {
+	input := `{"metadata":{"name":"web","labels":{"app":"web"},"uid":"1"},"spec":{"replicas":2},"items":[{"a":1,"b":2},{"a":3,"c":4}],"status":{}}`
+	tests := []struct {
+		name     string
+		pick     string
+		omit     string
+		expected string
+	}{
+		{"pick keys", "metadata,spec", "", `{"metadata":{"labels":{"app":"web"},"name":"web","uid":"1"},"spec":{"replicas":2}}`},
+		{"pick dotted paths", "metadata.name,spec.replicas", "", `{"metadata":{"name":"web"},"spec":{"replicas":2}}`},
+		{"pick through arrays", "items.a", "", `{"items":[{"a":1},{"a":3}]}`},
+		{"pick a missing key", "missing", "", `{}`},
+		{"pick within a scalar", "spec.replicas.x", "", `{"spec":{}}`},
+		{"pick with spaces", "spec, metadata.uid", "", `{"metadata":{"uid":"1"},"spec":{"replicas":2}}`},
+		{"omit keys", "", "metadata,items", `{"spec":{"replicas":2},"status":{}}`},
+		{"omit dotted paths", "", "metadata.uid,metadata.labels,items,status", `{"metadata":{"name":"web"},"spec":{"replicas":2}}`},
+		{"omit through arrays", "", "metadata,spec,status,items.a", `{"items":[{"b":2},{"c":4}]}`},
+		{"omit a missing key", "", "missing,metadata,items", `{"spec":{"replicas":2},"status":{}}`},
+	}
+	defer func(pick, omit string) {
+		pickList, omitList = pick, omit
+		parsePick()
+	}(pickList, omitList)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pickList, omitList = test.pick, test.omit
+			if err := parsePick(); err != nil {
+				t.Fatal(err)
+			}
+			output, err := convertText(jsonFormat, jsonFormat, input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if output != test.expected {
+				t.Errorf("expected %s, got %s", test.expected, output)
+			}
+		})
+	}
+}
+
+func TestPickArrayDocument(t *testing.T) {
+	defer func(pick string) {
+		pickList = pick
+		parsePick()
+	}(pickList)
+	pickList = "name"
+	if err := parsePick(); err != nil {
+		t.Fatal(err)
+	}
+	output, err := convertText(yamlFormat, jsonFormat, "- name: a\n  uid: 1\n- name: b\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `[{"name":"a"},{"name":"b"}]`; output != expected {
+		t.Errorf("expected %s, got %s", expected, output)
+	}
+}
+
+func TestParsePickErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		pick string
+		omit string
+	}{
+		{"both", "a", "b"},
+		{"empty pick key", "a..b", ""},
+		{"empty omit path", "", "a,,b"},
+		{"trailing dot", "a.", ""},
+	}
+	defer func(pick, omit string) {
+		pickList, omitList = pick, omit
+		parsePick()
+	}(pickList, omitList)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pickList, omitList = test.pick, test.omit
+			if err := parsePick(); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestWrapKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		wrapKey  string
+		jsonpath string
+		input    string
+		expected string
+	}{
+		{"object", "data", "", `{"a":1}`, `{"data":{"a":1}}`},
+		{"dotted key", "spec.template.data", "", `{"a":1}`, `{"spec":{"template":{"data":{"a":1}}}}`},
+		{"array", "items", "", `[1,2]`, `{"items":[1,2]}`},
+		{"scalar", "value", "", `"x"`, `{"value":"x"}`},
+		{"results", "names", "{.items[*].name}", `{"items":[{"name":"a"},{"name":"b"}]}`, `{"names":["a","b"]}`},
+		{"no key", "", "", `{"a":1}`, `{"a":1}`},
+	}
+	defer func(key string, templates []string) {
+		wrapKey, jsonpathTemplates = key, templates
+		parsePick()
+	}(wrapKey, jsonpathTemplates)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			wrapKey, jsonpathTemplates = test.wrapKey, nil
+			if test.jsonpath != "" {
+				jsonpathTemplates = []string{test.jsonpath}
+			}
+			if err := parsePick(); err != nil {
+				t.Fatal(err)
+			}
+			output, err := convertText(jsonFormat, jsonFormat, test.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if output != test.expected {
+				t.Errorf("expected %s, got %s", test.expected, output)
+			}
+		})
+	}
+}
+
+func TestWrapKeyErrors(t *testing.T) {
+	defer func(key string) {
+		wrapKey = key
+		parsePick()
+	}(wrapKey)
+	for _, key := range []string{"a,b", "a..b", ".a", "a."} {
+		wrapKey = key
+		if err := parsePick(); err == nil {
+			t.Errorf("expected an error for --wrap-key %q", key)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var pipeSpec string
+
+// pipeStage is one step of the --pipe, applied to the result of the previous one.
+type pipeStage struct {
+	spec  string
+	apply func(interface{}) (interface{}, error)
+}
+
+var pipeStages []pipeStage
+
+// parsePipe parses the --pipe, its stages separated by | and written
+// name=argument, a | within an argument being escaped as \|:
+//
+//	jsonpath=.spec          a JSONPath template, the braces being optional, or a $.spec query with --jsonpath-dialect standard
+//	jq=.items[]             a jq expression
+//	select=items[0].name    a dotted path, like --select
+//	where=spec.replicas>1   a predicate on the elements of the array, like --where
+//	set=spec.replicas=3     an assignment, like --set
+//	set-string=ver=1.10     an assignment of a string, like --set-string
+//	pick=metadata.name,spec the paths to keep, like --pick
+//	omit=status             the paths to remove, like --omit
+//	wrap=data               the dotted key to nest the value under, like --wrap-key
+func parsePipe() error {
+	pipeStages = nil
+	if pipeSpec == "" {
+		return nil
+	}
+	for _, spec := range splitPipe(pipeSpec) {
+		stage, err := parsePipeStage(spec)
+		if err != nil {
+			return usageError(fmt.Sprintf("invalid --pipe stage %q: %v", spec, err))
+		}
+		pipeStages = append(pipeStages, stage)
+	}
+	return nil
+}
+
+// splitPipe splits the --pipe on the unescaped |.
+func splitPipe(spec string) []string {
+	var stages []string
+	var stage strings.Builder
+	for i := 0; i < len(spec); i++ {
+		switch {
+		case spec[i] == '\\' && i+1 < len(spec) && spec[i+1] == '|':
+			stage.WriteByte('|')
+			i++
+		case spec[i] == '|':
+			stages = append(stages, strings.TrimSpace(stage.String()))
+			stage.Reset()
+		default:
+			stage.WriteByte(spec[i])
+		}
+	}
+	return append(stages, strings.TrimSpace(stage.String()))
+}
+
+func parsePipeStage(spec string) (pipeStage, error) {
+	i := strings.Index(spec, "=")
+	if i < 1 {
+		return pipeStage{}, errors.New("expected name=argument")
+	}
+	name, argument := strings.TrimSpace(spec[:i]), spec[i+1:]
+	stage := pipeStage{spec: spec}
+	switch name {
+	case "jsonpath":
+		template := argument
+		if jsonpathDialect == jsonpathStandard {
+			if _, err := jsonpathStandardQuery(template); err != nil {
+				return pipeStage{}, err
+			}
+		} else if !strings.HasPrefix(template, "{") {
+			template = "{" + template + "}"
+		}
+		stage.apply = func(object interface{}) (interface{}, error) {
+			return filter(object, template)
+		}
+	case "jq":
+		code, err := parseJQ(argument)
+		if err != nil {
+			return pipeStage{}, err
+		}
+		stage.apply = func(object interface{}) (interface{}, error) {
+			return runJQ(code, argument, object)
+		}
+	case "select":
+		segments, err := parseSelectPath(argument)
+		if err != nil {
+			return pipeStage{}, err
+		}
+		stage.apply = func(object interface{}) (interface{}, error) {
+			return selectValue(object, segments)
+		}
+	case "where":
+		predicate, err := parseWherePredicate(argument)
+		if err != nil {
+			return pipeStage{}, err
+		}
+		stage.apply = func(object interface{}) (interface{}, error) {
+			items, ok := object.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("the --pipe stage %q requires an array, not %s", spec, jsonKind(object))
+			}
+			kept := []interface{}{}
+			for _, item := range items {
+				if matchesWhere(item, []wherePredicate{predicate}) {
+					kept = append(kept, item)
+				}
+			}
+			return kept, nil
+		}
+	case "set", "set-string":
+		j := strings.Index(argument, "=")
+		if j < 1 {
+			return pipeStage{}, errors.New("expected path=value")
+		}
+		if _, err := parseSelectPath(argument[:j]); err != nil {
+			return pipeStage{}, err
+		}
+		stage.apply = func(object interface{}) (interface{}, error) {
+			return applySet(object, argument, name == "set-string")
+		}
+	case "pick", "omit":
+		paths, err := parseKeyPaths(argument)
+		if err != nil {
+			return pipeStage{}, err
+		}
+		if paths == nil {
+			return pipeStage{}, errors.New("expected the comma-separated paths")
+		}
+		stage.apply = func(object interface{}) (interface{}, error) {
+			if name == "pick" {
+				pickKeys(object, paths)
+			} else {
+				omitKeys(object, paths)
+			}
+			return object, nil
+		}
+	case "wrap":
+		paths, err := parseKeyPaths(argument)
+		if err != nil {
+			return pipeStage{}, err
+		}
+		if len(paths) != 1 {
+			return pipeStage{}, errors.New("expected a single dotted key")
+		}
+		stage.apply = func(object interface{}) (interface{}, error) {
+			for i := len(paths[0]) - 1; i >= 0; i-- {
+				object = map[string]interface{}{paths[0][i]: object}
+			}
+			return object, nil
+		}
+	default:
+		return pipeStage{}, fmt.Errorf("unknown stage %q, expected jsonpath, jq, select, where, set, set-string, pick, omit or wrap", name)
+	}
+	return stage, nil
+}
+
+// applyPipe runs the object through the --pipe stages, the several results
+// of a stage becoming the array the next stage applies to.
+func applyPipe(object interface{}) (interface{}, error) {
+	for _, stage := range pipeStages {
+		if object == nil {
+			return nil, nil
+		}
+		if results, ok := object.(jsonpathResults); ok {
+			object = []interface{}(results)
+		}
+		var err error
+		if object, err = stage.apply(object); err != nil {
+			return nil, err
+		}
+	}
+	return object, nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"golang.org/x/crypto/ssh/terminal"
+	"os"
+)
+
+const (
+	colorReset  = "\x1b[0m"
+	colorKey    = "\x1b[34;1m"
+	colorString = "\x1b[32m"
+	colorNumber = "\x1b[36m"
+	colorBool   = "\x1b[33m"
+	colorNull   = "\x1b[90m"
+)
+
+var pretty bool
+
+// outputIsTerminal reports whether the output goes to stdout and stdout is
+// an interactive terminal. The output of --output, --write, --output-dir,
+// --output-suffix, --explode and --to-clipboard goes to the files or the
+// clipboard instead, whatever stdout is.
+func outputIsTerminal() bool {
+	toStdout := outputPath == "" && !toClipboard && !writeInPlace && !explode && outputDir == "" && outputSuffix == ""
+	return toStdout && terminal.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// prettyJSON reports whether the JSON output should be indented for humans,
+// either because --pretty was given or because the output is a terminal.
+func prettyJSON() bool {
+	return pretty || outputIsTerminal()
+}
+
+// colorizeJSON highlights keys, strings, numbers, booleans and nulls
+// of a valid JSON document with ANSI escape sequences.
+func colorizeJSON(input []byte) []byte {
+	var output bytes.Buffer
+	for i := 0; i < len(input); {
+		c := input[i]
+		switch {
+		case c == '"':
+			end := i + 1
+			for end < len(input) && input[end] != '"' {
+				if input[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			end++
+			color := colorString
+			if isJSONKey(input[end:]) {
+				color = colorKey
+			}
+			writeColored(&output, color, input[i:end])
+			i = end
+		case c == '-' || (c >= '0' && c <= '9'):
+			end := i + 1
+			for end < len(input) && bytes.IndexByte([]byte("0123456789.eE+-"), input[end]) >= 0 {
+				end++
+			}
+			writeColored(&output, colorNumber, input[i:end])
+			i = end
+		case bytes.HasPrefix(input[i:], []byte("true")):
+			writeColored(&output, colorBool, input[i:i+4])
+			i += 4
+		case bytes.HasPrefix(input[i:], []byte("false")):
+			writeColored(&output, colorBool, input[i:i+5])
+			i += 5
+		case bytes.HasPrefix(input[i:], []byte("null")):
+			writeColored(&output, colorNull, input[i:i+4])
+			i += 4
+		default:
+			output.WriteByte(c)
+			i++
+		}
+	}
+	return output.Bytes()
+}
+
+// isJSONKey reports whether the string just before rest is an object key.
+func isJSONKey(rest []byte) bool {
+	rest = bytes.TrimLeft(rest, " \t\r\n")
+	return len(rest) > 0 && rest[0] == ':'
+}
+
+func writeColored(output *bytes.Buffer, color string, token []byte) {
+	output.WriteString(color)
+	output.Write(token)
+	output.WriteString(colorReset)
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"golang.org/x/crypto/ssh/terminal"
+	"io"
+	"os"
+	"time"
+)
+
+var (
+	showProgress  bool
+	forceProgress bool
+)
+
+// progressInterval throttles the updates of the bytes read by a stream.
+const progressInterval = 200 * time.Millisecond
+
+// progress is the line of --progress on stderr, rewritten on every update.
+// It is nil when there is no progress to report.
+var progress *progressLine
+
+type progressLine struct {
+	files, total int
+	written      bool
+}
+
+// startProgress starts reporting the progress of the total input files, with
+// --progress when stderr is a terminal, so that the logs stay clean, or always
+// with --force-progress.
+func startProgress(total int) {
+	if !forceProgress && !(showProgress && terminal.IsTerminal(int(os.Stderr.Fd()))) {
+		return
+	}
+	if total == 0 {
+		// stdin
+		total = 1
+	}
+	progress = &progressLine{total: total}
+}
+
+// stopProgress ends the progress line.
+func stopProgress() {
+	if progress != nil && progress.written {
+		fmt.Fprintln(os.Stderr)
+	}
+	progress = nil
+}
+
+func (p *progressLine) update(format string, a ...interface{}) {
+	if p == nil {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "\r\x1b[K"+format, a...)
+	p.written = true
+}
+
+// fileDone counts an input file as processed.
+func (p *progressLine) fileDone() {
+	if p == nil {
+		return
+	}
+	p.files++
+	p.update("processed %d/%d files", p.files, p.total)
+}
+
+// reader reports the bytes read from the streamed input.
+func (p *progressLine) reader(inputPath string, input io.ReadCloser) io.ReadCloser {
+	if p == nil {
+		return input
+	}
+	return &progressReader{ReadCloser: input, line: p, name: inputName(inputPath)}
+}
+
+type progressReader struct {
+	io.ReadCloser
+	line    *progressLine
+	name    string
+	read    int64
+	updated time.Time
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.read += int64(n)
+	if now := time.Now(); n > 0 && now.Sub(r.updated) >= progressInterval {
+		r.updated = now
+		r.line.update("processed %d/%d files, %s: %s read", r.line.files, r.line.total, r.name, formatBytes(r.read))
+	}
+	return n, err
+}
+
+// formatBytes formats a size with the largest unit it has at least one of.
+func formatBytes(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	value, prefix := float64(size)/unit, 0
+	for value >= unit && prefix < 3 {
+		value /= unit
+		prefix++
+	}
+	return fmt.Sprintf("%.1f %ciB", value, "KMGT"[prefix])
+}
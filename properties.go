@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"github.com/urfave/cli"
+	"strconv"
+	"strings"
+)
+
+var propertiesFormat = format{unmarshal: unmarshalProperties, stringValues: true}
+
+var propertiesExpand bool
+
+var propertiesExpandFlag = cli.BoolFlag{
+	Name:        "expand",
+	Usage:       "expand the dotted keys (a.b.c=1) into nested objects",
+	Destination: &propertiesExpand,
+}
+
+// unmarshalProperties decodes a Java .properties file into a flat map,
+// or into nested objects with --expand.
+func unmarshalProperties(input []byte) (interface{}, error) {
+	object := map[string]interface{}{}
+	scanner := bufio.NewScanner(bytes.NewReader(input))
+	var logical strings.Builder
+	for scanner.Scan() {
+		line := scanner.Text()
+		if logical.Len() == 0 {
+			trimmed := strings.TrimLeft(line, " \t\f")
+			if trimmed == "" || trimmed[0] == '#' || trimmed[0] == '!' {
+				continue
+			}
+			line = trimmed
+		} else {
+			// continuation lines start after their leading white space
+			line = strings.TrimLeft(line, " \t\f")
+		}
+		if continues(line) {
+			logical.WriteString(line[:len(line)-1])
+			continue
+		}
+		logical.WriteString(line)
+		key, value, err := parseProperty(logical.String())
+		if err != nil {
+			return nil, err
+		}
+		logical.Reset()
+		if err := setProperty(object, key, value); err != nil {
+			return nil, err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if logical.Len() > 0 {
+		key, value, err := parseProperty(logical.String())
+		if err != nil {
+			return nil, err
+		}
+		if err := setProperty(object, key, value); err != nil {
+			return nil, err
+		}
+	}
+	if len(object) == 0 {
+		return nil, nil
+	}
+	return object, nil
+}
+
+// continues reports whether the line ends with an odd number of backslashes,
+// which continues the logical line on the next one.
+func continues(line string) bool {
+	count := 0
+	for i := len(line) - 1; i >= 0 && line[i] == '\\'; i-- {
+		count++
+	}
+	return count%2 == 1
+}
+
+// parseProperty splits a logical line into its unescaped key and value. The key
+// ends at the first unescaped '=', ':' or white space.
+func parseProperty(line string) (string, string, error) {
+	end := 0
+	for end < len(line) {
+		c := line[end]
+		if c == '\\' {
+			end += 2
+			continue
+		}
+		if c == '=' || c == ':' || c == ' ' || c == '\t' || c == '\f' {
+			break
+		}
+		end++
+	}
+	if end > len(line) {
+		end = len(line)
+	}
+	rest := strings.TrimLeft(line[end:], " \t\f")
+	if strings.HasPrefix(rest, "=") || strings.HasPrefix(rest, ":") {
+		rest = strings.TrimLeft(rest[1:], " \t\f")
+	}
+	key, err := unescapeProperty(line[:end])
+	if err != nil {
+		return "", "", err
+	}
+	value, err := unescapeProperty(rest)
+	if err != nil {
+		return "", "", err
+	}
+	return key, value, nil
+}
+
+func unescapeProperty(escaped string) (string, error) {
+	if !strings.Contains(escaped, "\\") {
+		return escaped, nil
+	}
+	var unescaped strings.Builder
+	for i := 0; i < len(escaped); i++ {
+		c := escaped[i]
+		if c != '\\' || i+1 == len(escaped) {
+			unescaped.WriteByte(c)
+			continue
+		}
+		i++
+		switch escaped[i] {
+		case 't':
+			unescaped.WriteByte('\t')
+		case 'n':
+			unescaped.WriteByte('\n')
+		case 'r':
+			unescaped.WriteByte('\r')
+		case 'f':
+			unescaped.WriteByte('\f')
+		case 'u':
+			if i+5 > len(escaped) {
+				return "", fmt.Errorf("malformed \\uxxxx escape in %q", escaped)
+			}
+			code, err := strconv.ParseUint(escaped[i+1:i+5], 16, 16)
+			if err != nil {
+				return "", fmt.Errorf("malformed \\uxxxx escape in %q", escaped)
+			}
+			unescaped.WriteRune(rune(code))
+			i += 4
+		default:
+			unescaped.WriteByte(escaped[i])
+		}
+	}
+	return unescaped.String(), nil
+}
+
+// setProperty stores the value, nesting it under the dotted key with --expand.
+func setProperty(object map[string]interface{}, key, value string) error {
+	if !propertiesExpand {
+		object[key] = value
+		return nil
+	}
+	names := strings.Split(key, ".")
+	parent := object
+	for i, name := range names[:len(names)-1] {
+		switch child := parent[name].(type) {
+		case nil:
+			next := map[string]interface{}{}
+			parent[name] = next
+			parent = next
+		case map[string]interface{}:
+			parent = child
+		default:
+			return fmt.Errorf("cannot expand %q, %q already has a value", key, strings.Join(names[:i+1], "."))
+		}
+	}
+	name := names[len(names)-1]
+	if _, ok := parent[name].(map[string]interface{}); ok {
+		return fmt.Errorf("cannot expand %q, it already has nested keys", key)
+	}
+	parent[name] = value
+	return nil
+}
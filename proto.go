@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/urfave/cli"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"io/ioutil"
+)
+
+var (
+	protoDescriptorPath string
+	protoMessageName    string
+	protoMessage        protoreflect.MessageDescriptor
+)
+
+var protoFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:        "descriptor",
+		Usage:       "the FileDescriptorSet file describing the message, as written by protoc --descriptor_set_out --include_imports",
+		Destination: &protoDescriptorPath,
+	},
+	cli.StringFlag{
+		Name:        "message",
+		Usage:       "the fully-qualified name of the message type, e.g. my.package.Request",
+		Destination: &protoMessageName,
+	},
+}
+
+var protoFormat = format{unmarshal: unmarshalProto}
+
+// loadProtoMessage finds the --message type in the --descriptor set.
+func loadProtoMessage() error {
+	if protoDescriptorPath == "" || protoMessageName == "" {
+		return usageError("proto2json requires --descriptor and --message")
+	}
+	content, err := ioutil.ReadFile(protoDescriptorPath)
+	if err != nil {
+		return err
+	}
+	var set descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(content, &set); err != nil {
+		return fmt.Errorf("cannot parse the descriptor set %s: %v", protoDescriptorPath, err)
+	}
+	files, err := protodesc.NewFiles(&set)
+	if err != nil {
+		return fmt.Errorf("invalid descriptor set %s: %v", protoDescriptorPath, err)
+	}
+	descriptor, err := files.FindDescriptorByName(protoreflect.FullName(protoMessageName))
+	if err != nil {
+		return usageError(fmt.Sprintf("message type %q not found in the descriptor set %s", protoMessageName, protoDescriptorPath))
+	}
+	message, ok := descriptor.(protoreflect.MessageDescriptor)
+	if !ok {
+		return usageError(fmt.Sprintf("%q is not a message type in the descriptor set %s", protoMessageName, protoDescriptorPath))
+	}
+	protoMessage = message
+	return nil
+}
+
+// unmarshalProto decodes the binary message and converts it
+// into the structure the JSON decoder produces through protojson.
+func unmarshalProto(input []byte) (interface{}, error) {
+	message := dynamicpb.NewMessage(protoMessage)
+	if err := proto.Unmarshal(input, message); err != nil {
+		return nil, err
+	}
+	encoded, err := protojson.Marshal(message)
+	if err != nil {
+		return nil, err
+	}
+	var object interface{}
+	if err := json.Unmarshal(encoded, &object); err != nil {
+		return nil, err
+	}
+	return object, nil
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+)
+
+var normalizeQuantities bool
+
+// quantityPattern matches the Kubernetes resource quantities, e.g. 100Mi,
+// 500m or 1e3, a plain number being left alone.
+var quantityPattern = regexp.MustCompile(`^([+-]?(?:[0-9]+(?:\.[0-9]*)?|\.[0-9]+))(Ki|Mi|Gi|Ti|Pi|Ei|n|u|m|k|M|G|T|P|E|[eE][+-]?[0-9]+)$`)
+
+// quantitySuffixes are the multipliers of the binary and decimal suffixes.
+var quantitySuffixes = map[string]float64{
+	"Ki": 1 << 10, "Mi": 1 << 20, "Gi": 1 << 30, "Ti": 1 << 40, "Pi": 1 << 50, "Ei": 1 << 60,
+	"k": 1e3, "M": 1e6, "G": 1e9, "T": 1e12, "P": 1e15, "E": 1e18,
+}
+
+// quantityDivisors are the divisors of the fractional suffixes, which divide
+// exactly where multiplying by 1e-3 makes 9m 0.009000000000000001.
+var quantityDivisors = map[string]float64{"n": 1e9, "u": 1e6, "m": 1e3}
+
+// addQuantityValues adds a sibling key to the quantities of the resources.requests
+// and resources.limits of the containers, and of the capacity and allocatable of the
+// nodes and volumes, named after its key with a Value suffix, holding the quantity in
+// its base unit: memory: 100Mi gets memoryValue: 104857600 bytes and cpu: 500m gets
+// cpuValue: 0.5 cores. The other strings that look like quantities, e.g. a version: 1m,
+// are left alone, and so is an existing sibling key.
+func addQuantityValues(value interface{}) {
+	addResourceQuantities(value, "", "")
+}
+
+// addResourceQuantities adds the quantity values to the value of the key, whose
+// object is itself the value of the parent key.
+func addResourceQuantities(value interface{}, parent, key string) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if parent == "resources" && (key == "requests" || key == "limits") || key == "capacity" || key == "allocatable" {
+			// adding to the map while ranging over it may or may not range over the added keys
+			values := map[string]float64{}
+			for name, item := range v {
+				text, ok := item.(string)
+				if !ok {
+					continue
+				}
+				if _, exists := v[name+"Value"]; exists {
+					continue
+				}
+				if number, ok := parseQuantity(text); ok {
+					values[name+"Value"] = number
+				}
+			}
+			for name, number := range values {
+				v[name] = number
+			}
+			return
+		}
+		for name, item := range v {
+			addResourceQuantities(item, key, name)
+		}
+	case []interface{}:
+		for _, item := range v {
+			addResourceQuantities(item, "", "")
+		}
+	case jsonpathResults:
+		for _, item := range v {
+			addResourceQuantities(item, "", "")
+		}
+	}
+}
+
+// parseQuantity returns the value of a quantity in its base unit.
+func parseQuantity(text string) (float64, bool) {
+	match := quantityPattern.FindStringSubmatch(text)
+	if match == nil {
+		return 0, false
+	}
+	number, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return 0, false
+	}
+	suffix := match[2]
+	if multiplier, ok := quantitySuffixes[suffix]; ok {
+		number *= multiplier
+	} else if divisor, ok := quantityDivisors[suffix]; ok {
+		number /= divisor
+	} else {
+		exponent, err := strconv.Atoi(suffix[1:])
+		if err != nil {
+			return 0, false
+		}
+		if exponent < 0 {
+			number /= math.Pow10(-exponent)
+		} else {
+			number *= math.Pow10(exponent)
+		}
+	}
+	// 0.1 * 1e3 is not quite 100
+	if rounded := math.Round(number); math.Abs(number-rounded) < 1e-9*math.Max(1, math.Abs(number)) {
+		number = rounded
+	}
+	return number, true
+}
@@ -0,0 +1,74 @@
+package main
+
+import "testing"
+
+func TestParseQuantity(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected float64
+		ok       bool
+	}{
+		{"100Mi", 104857600, true},
+		{"1Gi", 1073741824, true},
+		{"1.5Gi", 1610612736, true},
+		{"500m", 0.5, true},
+		{"100m", 0.1, true},
+		{"9m", 0.009, true},
+		{"1e-3", 0.001, true},
+		{"1Ki", 1024, true},
+		{"2k", 2000, true},
+		{"1M", 1e6, true},
+		{"1e3", 1000, true},
+		{"0.1k", 100, true},
+		{"250n", 250e-9, true},
+		{"+1Mi", 1048576, true},
+		{"1", 0, false},
+		{"1.5", 0, false},
+		{"Mi", 0, false},
+		{"1MiB", 0, false},
+		{"1 Mi", 0, false},
+		{"large", 0, false},
+	}
+	for _, test := range tests {
+		t.Run(test.text, func(t *testing.T) {
+			number, ok := parseQuantity(test.text)
+			if ok != test.ok || number != test.expected {
+				t.Errorf("expected %v %v, got %v %v", test.expected, test.ok, number, ok)
+			}
+		})
+	}
+}
+
+func TestNormalizeQuantities(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"container resources", `{"spec":{"containers":[{"resources":{"requests":{"memory":"100Mi","cpu":"500m"},"limits":{"memory":"1Gi","cpu":2}}}]}}`,
+			`{"spec":{"containers":[{"resources":{"limits":{"cpu":2,"memory":"1Gi","memoryValue":1073741824},"requests":{"cpu":"500m","cpuValue":0.5,"memory":"100Mi","memoryValue":104857600}}}]}}`},
+		{"node capacity", `{"status":{"capacity":{"memory":"16Gi","pods":"110"},"allocatable":{"cpu":"3900m"}}}`,
+			`{"status":{"allocatable":{"cpu":"3900m","cpuValue":3.9},"capacity":{"memory":"16Gi","memoryValue":17179869184,"pods":"110"}}}`},
+		{"other keys", `{"version":"1m","requests":{"memory":"1Mi"},"spec":{"limits":{"memory":"1Mi"}}}`,
+			`{"requests":{"memory":"1Mi"},"spec":{"limits":{"memory":"1Mi"}},"version":"1m"}`},
+		{"existing sibling", `{"resources":{"requests":{"memory":"1Mi","memoryValue":"kept"}}}`,
+			`{"resources":{"requests":{"memory":"1Mi","memoryValue":"kept"}}}`},
+		{"documents", `[{"resources":{"limits":{"cpu":"1m"}}},{"resources":{"limits":{"cpu":"2"}}}]`,
+			`[{"resources":{"limits":{"cpu":"1m","cpuValue":0.001}}},{"resources":{"limits":{"cpu":"2"}}}]`},
+		{"many quantities", `{"resources":{"limits":{"a":"1Ki","b":"2Ki","c":"3Ki","d":"4Ki","e":"5Ki","f":"6Ki","g":"7Ki","h":"8Ki"}}}`,
+			`{"resources":{"limits":{"a":"1Ki","aValue":1024,"b":"2Ki","bValue":2048,"c":"3Ki","cValue":3072,"d":"4Ki","dValue":4096,"e":"5Ki","eValue":5120,"f":"6Ki","fValue":6144,"g":"7Ki","gValue":7168,"h":"8Ki","hValue":8192}}}`},
+	}
+	defer func(enabled bool) { normalizeQuantities = enabled }(normalizeQuantities)
+	normalizeQuantities = true
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output, err := convertText(jsonFormat, jsonFormat, test.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if output != test.expected {
+				t.Errorf("expected %s, got %s", test.expected, output)
+			}
+		})
+	}
+}
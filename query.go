@@ -0,0 +1,178 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/urfave/cli"
+	"net/url"
+	"strings"
+)
+
+var queryFormat = format{unmarshal: unmarshalQuery, marshal: marshalQuery, separator: "\n", stringValues: true}
+
+var queryBrackets bool
+
+var queryBracketsFlag = cli.BoolFlag{
+	Name:        "brackets",
+	Usage:       "write the nested objects with the bracket notation a[b]=1, and read the bracketed keys back into objects",
+	Destination: &queryBrackets,
+}
+
+// unmarshalQuery decodes a URL query string, with or without the leading ?,
+// into an object of strings. A repeated key becomes an array of its values.
+func unmarshalQuery(input []byte) (interface{}, error) {
+	query := strings.TrimPrefix(strings.TrimSpace(string(input)), "?")
+	if query == "" {
+		return nil, nil
+	}
+	object := map[string]interface{}{}
+	for _, pair := range strings.Split(query, "&") {
+		if pair == "" {
+			continue
+		}
+		rawKey, rawValue := pair, ""
+		if separator := strings.Index(pair, "="); separator >= 0 {
+			rawKey, rawValue = pair[:separator], pair[separator+1:]
+		}
+		key, err := url.QueryUnescape(rawKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key %q: %v", rawKey, err)
+		}
+		value, err := url.QueryUnescape(rawValue)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value of %q: %v", key, err)
+		}
+		path := []string{key}
+		if queryBrackets {
+			if path, err = parseBracketKey(key); err != nil {
+				return nil, fmt.Errorf("invalid key %q: %v", key, err)
+			}
+		}
+		if err := setQueryValue(object, path, value); err != nil {
+			return nil, fmt.Errorf("invalid key %q: %v", key, err)
+		}
+	}
+	if len(object) == 0 {
+		return nil, nil
+	}
+	return object, nil
+}
+
+// parseBracketKey splits a[b][c] into a, b and c. An empty a[] segment
+// can only be the last one, appending to an array.
+func parseBracketKey(key string) ([]string, error) {
+	open := strings.Index(key, "[")
+	if open < 0 {
+		return []string{key}, nil
+	}
+	path := []string{key[:open]}
+	rest := key[open:]
+	for rest != "" {
+		end := strings.Index(rest, "]")
+		if rest[0] != '[' || end < 0 {
+			return nil, errors.New("unbalanced brackets")
+		}
+		path = append(path, rest[1:end])
+		rest = rest[end+1:]
+	}
+	for _, segment := range path[:len(path)-1] {
+		if segment == "" {
+			return nil, errors.New("only the last brackets can be empty")
+		}
+	}
+	return path, nil
+}
+
+// setQueryValue sets the value at the path of keys, turning the values
+// of a repeated key into an array.
+func setQueryValue(object map[string]interface{}, path []string, value string) error {
+	key := path[0]
+	if len(path) > 1 && path[1] == "" {
+		// a[]=1 always appends
+		path = path[:1]
+		if _, ok := object[key]; !ok {
+			object[key] = []interface{}{}
+		}
+	}
+	if len(path) > 1 {
+		child, ok := object[key].(map[string]interface{})
+		if !ok {
+			if _, exists := object[key]; exists {
+				return fmt.Errorf("%q is both a value and an object", key)
+			}
+			child = map[string]interface{}{}
+			object[key] = child
+		}
+		return setQueryValue(child, path[1:], value)
+	}
+	switch existing := object[key].(type) {
+	case nil:
+		object[key] = value
+	case string:
+		object[key] = []interface{}{existing, value}
+	case []interface{}:
+		object[key] = append(existing, value)
+	default:
+		return fmt.Errorf("%q is both an object and a value", key)
+	}
+	return nil
+}
+
+// marshalQuery encodes a flat object into a URL query string, the arrays as
+// repeated keys and the nested objects with the bracket notation of --brackets,
+// which also adds the a[]=1 brackets to the arrays.
+func marshalQuery(object interface{}) ([]byte, error) {
+	if _, ok := object.(map[string]interface{}); !ok {
+		return nil, errors.New("query string output requires a top-level object")
+	}
+	var pairs []string
+	if err := collectQuery(&pairs, "", object); err != nil {
+		return nil, err
+	}
+	return []byte(strings.Join(pairs, "&")), nil
+}
+
+func collectQuery(pairs *[]string, key string, value interface{}) error {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if key != "" && !queryBrackets {
+			return fmt.Errorf("cannot represent the nested object %q in a query string, only flat objects are supported (use --brackets)", key)
+		}
+		for _, name := range orderedKeys(v) {
+			if err := collectQuery(pairs, joinQueryKey(key, name), v[name]); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		if queryBrackets {
+			// a[]=1 reads back as an array even with a single element
+			key += "[]"
+		}
+		for _, item := range v {
+			switch item.(type) {
+			case map[string]interface{}, []interface{}:
+				return fmt.Errorf("cannot represent the array of objects or arrays %q in a query string", strings.TrimSuffix(key, "[]"))
+			}
+			if err := collectQuery(pairs, key, item); err != nil {
+				return err
+			}
+		}
+	case nil:
+		*pairs = append(*pairs, key+"=")
+	default:
+		field, err := csvField(v)
+		if err != nil {
+			return err
+		}
+		*pairs = append(*pairs, key+"="+url.QueryEscape(field))
+	}
+	return nil
+}
+
+// joinQueryKey appends the escaped key, in brackets below the top level.
+func joinQueryKey(parent, key string) string {
+	if parent == "" {
+		return url.QueryEscape(key)
+	}
+	return parent + "[" + url.QueryEscape(key) + "]"
+}
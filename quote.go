@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/urfave/cli"
+	yamlv2 "gopkg.in/yaml.v2"
+	yamlv3 "gopkg.in/yaml.v3"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var quoteStyle string
+
+var quoteStyleFlag = cli.StringFlag{
+	Name:        "quote-style",
+	Usage:       "how the YAML strings are quoted: 'plain' only when needed, 'single' or 'double' quotes when needed, or 'force' double quotes for every string",
+	Value:       "plain",
+	Destination: &quoteStyle,
+}
+
+// marshalQuotedYAML is marshalYAML with the strings quoted per --quote-style,
+// which yaml.v2 cannot control, so the document is built as yaml.v3 nodes.
+func marshalQuotedYAML(object interface{}) ([]byte, error) {
+	var style yamlv3.Style
+	switch quoteStyle {
+	case "single":
+		style = yamlv3.SingleQuotedStyle
+	case "double", "force":
+		style = yamlv3.DoubleQuotedStyle
+	default:
+		return nil, usageError(fmt.Sprintf("invalid --quote-style %q, expected 'plain', 'single', 'double' or 'force'", quoteStyle))
+	}
+	var output bytes.Buffer
+	encoder := yamlv3.NewEncoder(&output)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(yamlNode(object, style)); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return output.Bytes(), nil
+}
+
+// yamlNode converts the object into yaml.v3 nodes, the keys sorted like yaml.v2 does.
+func yamlNode(value interface{}, style yamlv3.Style) *yamlv3.Node {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		node := &yamlv3.Node{Kind: yamlv3.MappingNode, Tag: "!!map"}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			// the keys are only quoted when needed, even with force
+			name := &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: key}
+			if needsQuotes(key) {
+				name.Style = style
+			}
+			node.Content = append(node.Content, name, yamlNode(v[key], style))
+		}
+		return node
+	case []interface{}:
+		node := &yamlv3.Node{Kind: yamlv3.SequenceNode, Tag: "!!seq"}
+		for _, item := range v {
+			node.Content = append(node.Content, yamlNode(item, style))
+		}
+		return node
+	case string:
+		node := &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: v}
+		if quoteStyle == "force" || needsQuotes(v) {
+			node.Style = style
+		}
+		return node
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+			return &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!int", Value: strconv.FormatInt(int64(v), 10)}
+		}
+		return &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!float", Value: strconv.FormatFloat(v, 'g', -1, 64)}
+	case int:
+		return &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!int", Value: strconv.Itoa(v)}
+	case bool:
+		return &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(v)}
+	case nil:
+		return &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!null", Value: "null"}
+	}
+	switch reflected := reflect.ValueOf(value); reflected.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!int", Value: strconv.FormatInt(reflected.Int(), 10)}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!int", Value: strconv.FormatUint(reflected.Uint(), 10)}
+	case reflect.Float32:
+		return &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!float", Value: strconv.FormatFloat(reflected.Float(), 'g', -1, 32)}
+	}
+	// any other value is written as its text, quoted like a string
+	return yamlNode(fmt.Sprint(value), style)
+}
+
+// needsQuotes reports whether the string would read back as another type,
+// like yes, 12345 or null, which yaml.v2 quotes.
+func needsQuotes(s string) bool {
+	if strings.Contains(s, "\n") {
+		// left to the literal style
+		return false
+	}
+	output, err := yamlv2.Marshal(s)
+	return err == nil && len(output) > 0 && (output[0] == '"' || output[0] == '\'')
+}
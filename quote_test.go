@@ -0,0 +1,104 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNeedsQuotes(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected bool
+	}{
+		{"yes", true},
+		{"no", true},
+		{"true", true},
+		{"12345", true},
+		{"01234", true},
+		{"1.5", true},
+		{"null", true},
+		{"~", true},
+		{"", true},
+		{"text", false},
+		{"hello world", false},
+		{"a: b", true},
+		{"line 1\nline 2", false},
+	}
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			if quoted := needsQuotes(test.value); quoted != test.expected {
+				t.Errorf("expected %v, got %v", test.expected, quoted)
+			}
+		})
+	}
+}
+
+func TestQuoteStyle(t *testing.T) {
+	input := `{"answer":"yes","zip":"12345","none":"null","name":"2fy","count":3,"on":true,"empty":null}`
+	tests := []struct {
+		style    string
+		expected string
+	}{
+		{"plain", "answer: \"yes\"\ncount: 3\nempty: null\nname: 2fy\nnone: \"null\"\n\"on\": true\nzip: \"12345\"\n"},
+		{"single", "answer: 'yes'\ncount: 3\nempty: null\nname: 2fy\nnone: 'null'\n'on': true\nzip: '12345'\n"},
+		{"double", "answer: \"yes\"\ncount: 3\nempty: null\nname: 2fy\nnone: \"null\"\n\"on\": true\nzip: \"12345\"\n"},
+		{"force", "answer: \"yes\"\ncount: 3\nempty: null\nname: \"2fy\"\nnone: \"null\"\n\"on\": true\nzip: \"12345\"\n"},
+	}
+	defer func(style string) { quoteStyle = style }(quoteStyle)
+	for _, test := range tests {
+		t.Run(test.style, func(t *testing.T) {
+			quoteStyle = test.style
+			output, err := convertText(jsonFormat, yamlFormat, input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if output != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, output)
+			}
+			decoded, err := unmarshalYAML([]byte(output))
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected, _ := unmarshalJSON([]byte(input))
+			if !reflect.DeepEqual(decoded, expected) {
+				t.Errorf("expected %#v to read back, got %#v", expected, decoded)
+			}
+		})
+	}
+}
+
+func TestQuoteStyleInvalid(t *testing.T) {
+	defer func(style string) { quoteStyle = style }(quoteStyle)
+	quoteStyle = "backticks"
+	if _, err := convertText(jsonFormat, yamlFormat, `{"a":"b"}`); err == nil {
+		t.Error("expected an error for --quote-style backticks")
+	}
+}
+
+func TestQuoteStyleNumbers(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    interface{}
+		expected string
+	}{
+		{"int64", int64(1), "a: 1\n"},
+		{"preserved int", 9007199254740993, "a: 9007199254740993\n"},
+		{"uint8", uint8(255), "a: 255\n"},
+		{"float32", float32(0.5), "a: 0.5\n"},
+		{"whole float64", 3.0, "a: 3\n"},
+		{"string of digits", "1", "a: \"1\"\n"},
+	}
+	defer func(style string) { quoteStyle = style }(quoteStyle)
+	quoteStyle = "double"
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output, err := marshalQuotedYAML(map[string]interface{}{"a": test.value})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(output) != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, output)
+			}
+		})
+	}
+}
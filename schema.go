@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"strings"
+)
+
+var (
+	schemaPath string
+	schema     *jsonschema.Schema
+)
+
+// compileSchema compiles the --schema file the inputs are validated against.
+func compileSchema() error {
+	if schemaPath == "" {
+		return nil
+	}
+	compiled, err := jsonschema.Compile(schemaPath)
+	if err != nil {
+		return fmt.Errorf("invalid JSON Schema %s: %v", schemaPath, err)
+	}
+	schema = compiled
+	return nil
+}
+
+// yamlStreamDocuments is the number of documents of the YAML stream decoded
+// last, telling the array of its documents from a single array document.
+var yamlStreamDocuments int
+
+// validateSchema validates the decoded input against the --schema,
+// listing every validation error with the JSON pointer of the invalid value.
+// The documents of a YAML stream are validated one at a time.
+func validateSchema(inputPath string, object interface{}) error {
+	if schema == nil {
+		return nil
+	}
+	count := yamlStreamDocuments
+	yamlStreamDocuments = 0
+	if documents, ok := object.([]interface{}); ok && count > 1 && len(documents) == count {
+		for i, document := range documents {
+			if err := validateDocument(fmt.Sprintf("%s document %d", inputName(inputPath), i+1), document); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return validateDocument(inputName(inputPath), object)
+}
+
+func validateDocument(name string, object interface{}) error {
+	// the validator expects the values the JSON decoder produces
+	encoded, err := json.Marshal(object)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	var instance interface{}
+	if err := decoder.Decode(&instance); err != nil {
+		return err
+	}
+	err = schema.Validate(instance)
+	validationErr, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		return err
+	}
+	var message strings.Builder
+	fmt.Fprintf(&message, "%s does not conform to the schema %s:", name, schemaPath)
+	for _, leaf := range validationLeaves(validationErr) {
+		location := leaf.InstanceLocation
+		if location == "" {
+			location = "/"
+		}
+		fmt.Fprintf(&message, "\n  %s: %s", location, leaf.Message)
+	}
+	return exitError(message.String(), exitFailure)
+}
+
+// validationLeaves returns the errors that have no nested causes,
+// which are the ones that tell what is actually wrong.
+func validationLeaves(err *jsonschema.ValidationError) []*jsonschema.ValidationError {
+	if len(err.Causes) == 0 {
+		return []*jsonschema.ValidationError{err}
+	}
+	var leaves []*jsonschema.ValidationError
+	for _, cause := range err.Causes {
+		leaves = append(leaves, validationLeaves(cause)...)
+	}
+	return leaves
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// secretFormat decodes the Kubernetes Secret manifests read like from
+// into the plain key/value pairs of their data.
+func secretFormat(from format) format {
+	return format{
+		unmarshal: func(input []byte) (interface{}, error) {
+			object, err := from.unmarshal(input)
+			if err != nil || object == nil {
+				return object, err
+			}
+			if documents, ok := object.([]interface{}); ok {
+				secrets := make([]interface{}, len(documents))
+				for i, document := range documents {
+					if secrets[i], err = decodeSecret(document); err != nil {
+						return nil, fmt.Errorf("document %d: %v", i+1, err)
+					}
+				}
+				return secrets, nil
+			}
+			return decodeSecret(object)
+		},
+	}
+}
+
+// decodeSecret base64-decodes the values of .data, and adds the plain values
+// of .stringData, which take precedence like the API server does.
+func decodeSecret(object interface{}) (interface{}, error) {
+	secret, ok := object.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected a Secret manifest, not %s", jsonKind(object))
+	}
+	if kind, ok := secret["kind"]; ok && kind != "Secret" {
+		return nil, fmt.Errorf("expected a Secret manifest, not a %v", kind)
+	}
+	decoded := map[string]interface{}{}
+	data, _ := secret["data"].(map[string]interface{})
+	var failures []string
+	for key, value := range data {
+		content, err := decodeBase64Value(value)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", key, err))
+			continue
+		}
+		decoded[key] = string(content)
+	}
+	if len(failures) > 0 {
+		sort.Strings(failures)
+		return nil, fmt.Errorf("cannot decode the Secret data:\n  %s", strings.Join(failures, "\n  "))
+	}
+	stringData, _ := secret["stringData"].(map[string]interface{})
+	for key, value := range stringData {
+		decoded[key] = value
+	}
+	return decoded, nil
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	selectPath     string
+	selectSegments []selectSegment
+)
+
+// selectSegment is either a map key or, when index is set, an array index.
+type selectSegment struct {
+	key   string
+	index *int
+}
+
+func (s selectSegment) String() string {
+	if s.index != nil {
+		return fmt.Sprintf("[%d]", *s.index)
+	}
+	return s.key
+}
+
+// parseSelect parses the --select path, a dotted list of keys
+// where array indexes are written [N], e.g. items[0].metadata.name,
+// and the keys with dots or brackets ['a.b'].
+func parseSelect() error {
+	if selectPath == "" {
+		return nil
+	}
+	if len(jsonpathTemplates) > 0 || jqExpression != "" {
+		return usageError("--select cannot be combined with --jsonpath, --jsonpath-file or --jq")
+	}
+	segments, err := parseSelectPath(selectPath)
+	if err != nil {
+		return usageError(fmt.Sprintf("invalid --select path %q: %v", selectPath, err))
+	}
+	selectSegments = segments
+	return nil
+}
+
+func parseSelectPath(path string) ([]selectSegment, error) {
+	var segments []selectSegment
+	rest := path
+	for {
+		end := strings.IndexAny(rest, ".[")
+		if end < 0 {
+			end = len(rest)
+		}
+		key := rest[:end]
+		rest = rest[end:]
+		if key != "" {
+			segments = append(segments, selectSegment{key: key})
+		} else if rest == "" || rest[0] == '.' {
+			return nil, fmt.Errorf("empty key")
+		}
+		for strings.HasPrefix(rest, "[") {
+			if strings.HasPrefix(rest, "['") {
+				key, length, err := parseQuotedKey(rest)
+				if err != nil {
+					return nil, err
+				}
+				segments = append(segments, selectSegment{key: key})
+				rest = rest[length:]
+				continue
+			}
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("malformed index %q", rest)
+			}
+			index, err := strconv.Atoi(rest[1:end])
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("malformed index %q", rest[:end+1])
+			}
+			segments = append(segments, selectSegment{index: &index})
+			rest = rest[end+1:]
+		}
+		if rest == "" {
+			return segments, nil
+		}
+		if rest[0] != '.' {
+			return nil, fmt.Errorf("malformed index %q", rest)
+		}
+		rest = rest[1:]
+	}
+}
+
+// parseQuotedKey reads the ['key'] at the start of the path, where a
+// backslash escapes a quote or a backslash, returning the key and the
+// length of its brackets.
+func parseQuotedKey(path string) (string, int, error) {
+	var key strings.Builder
+	for i := 2; i < len(path); i++ {
+		switch path[i] {
+		case '\\':
+			if i+1 < len(path) {
+				i++
+				key.WriteByte(path[i])
+			}
+		case '\'':
+			if i+1 < len(path) && path[i+1] == ']' {
+				return key.String(), i + 2, nil
+			}
+			return "", 0, fmt.Errorf("malformed key %q, expected ] after the quote", path[:i+1])
+		default:
+			key.WriteByte(path[i])
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated key %q", path)
+}
+
+// selectValue looks the --select path, or the segments of the --pipe select stage, up in the object.
+func selectValue(object interface{}, segments []selectSegment) (interface{}, error) {
+	value := object
+	for i, segment := range segments {
+		parent := joinSelectPath(segments[:i])
+		if segment.index != nil {
+			items, ok := value.([]interface{})
+			if !ok {
+				return nil, exitError(fmt.Sprintf("cannot select %v, %s is not an array", segment, parent), exitFailure)
+			}
+			if *segment.index >= len(items) {
+				return nil, exitError(fmt.Sprintf("cannot select %v, %s has %d items", segment, parent, len(items)), exitFailure)
+			}
+			value = items[*segment.index]
+			continue
+		}
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, exitError(fmt.Sprintf("cannot select %q, %s is not an object", segment.key, parent), exitFailure)
+		}
+		if value, ok = object[segment.key]; !ok {
+			return nil, exitError(fmt.Sprintf("cannot select %q, %s has no such key", segment.key, parent), exitFailure)
+		}
+	}
+	return value, nil
+}
+
+// joinSelectPath formats the segments for the error messages,
+// the empty path being the document itself.
+func joinSelectPath(segments []selectSegment) string {
+	if len(segments) == 0 {
+		return "the document"
+	}
+	path := ""
+	for _, segment := range segments {
+		if segment.index != nil {
+			path += segment.String()
+		} else {
+			path = joinPathKey(path, segment.key)
+		}
+	}
+	return strconv.Quote(path)
+}
+
+// lookupSegments is selectValue for the given segments,
+// reporting whether the value was found instead of why it was not.
+func lookupSegments(value interface{}, segments []selectSegment) (interface{}, bool) {
+	for _, segment := range segments {
+		if segment.index != nil {
+			items, ok := value.([]interface{})
+			if !ok || *segment.index >= len(items) {
+				return nil, false
+			}
+			value = items[*segment.index]
+			continue
+		}
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = object[segment.key]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
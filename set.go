@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"github.com/urfave/cli"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	setValues       cli.StringSlice
+	setStringValues cli.StringSlice
+)
+
+// applySets assigns the --set and then the --set-string values to the object,
+// creating the missing objects and arrays of their dotted paths.
+func applySets(object interface{}) (interface{}, error) {
+	for _, assignment := range setValues {
+		var err error
+		if object, err = applySet(object, assignment, false); err != nil {
+			return nil, err
+		}
+	}
+	for _, assignment := range setStringValues {
+		var err error
+		if object, err = applySet(object, assignment, true); err != nil {
+			return nil, err
+		}
+	}
+	return object, nil
+}
+
+func applySet(object interface{}, assignment string, asString bool) (interface{}, error) {
+	i := strings.Index(assignment, "=")
+	if i < 1 {
+		return nil, usageError(fmt.Sprintf("invalid --set %q, expected path=value", assignment))
+	}
+	segments, err := parseSelectPath(assignment[:i])
+	if err != nil {
+		return nil, usageError(fmt.Sprintf("invalid --set path %q: %v", assignment[:i], err))
+	}
+	var value interface{} = assignment[i+1:]
+	if !asString {
+		value = inferValue(assignment[i+1:])
+	}
+	return setPath(object, segments, value, assignment[:i])
+}
+
+// jsonNumberPattern matches the JSON number syntax, which has no NaN or infinities.
+var jsonNumberPattern = regexp.MustCompile(`^-?(?:0|[1-9][0-9]*)(?:\.[0-9]+)?(?:[eE][+-]?[0-9]+)?$`)
+
+// inferValue types the --set value like YAML would: numbers, booleans, null or a string.
+// Only the finite JSON numbers are numbers, nan or 1e999 stay strings.
+func inferValue(text string) interface{} {
+	switch text {
+	case "true":
+		return true
+	case "false":
+		return false
+	case "null":
+		return nil
+	}
+	if !jsonNumberPattern.MatchString(text) {
+		return text
+	}
+	if number, err := strconv.ParseFloat(text, 64); err == nil {
+		return number
+	}
+	return text
+}
+
+// setPath sets the value under the segments of the node, replacing what is there.
+// An array index can be at most the length of the array, appending an item.
+func setPath(node interface{}, segments []selectSegment, value interface{}, path string) (interface{}, error) {
+	if len(segments) == 0 {
+		return value, nil
+	}
+	segment := segments[0]
+	if segment.index != nil {
+		if node == nil {
+			node = []interface{}{}
+		}
+		items, ok := node.([]interface{})
+		if !ok {
+			return nil, exitError(fmt.Sprintf("cannot set %q, it goes through %s", path, jsonKind(node)), exitFailure)
+		}
+		if *segment.index > len(items) {
+			return nil, exitError(fmt.Sprintf("cannot set %q, the index %d is beyond the end of the %d items", path, *segment.index, len(items)), exitFailure)
+		}
+		if *segment.index == len(items) {
+			items = append(items, nil)
+		}
+		item, err := setPath(items[*segment.index], segments[1:], value, path)
+		if err != nil {
+			return nil, err
+		}
+		items[*segment.index] = item
+		return items, nil
+	}
+	if node == nil {
+		node = map[string]interface{}{}
+	}
+	object, ok := node.(map[string]interface{})
+	if !ok {
+		return nil, exitError(fmt.Sprintf("cannot set %q, it goes through %s", path, jsonKind(node)), exitFailure)
+	}
+	item, err := setPath(object[segment.key], segments[1:], value, path)
+	if err != nil {
+		return nil, err
+	}
+	object[segment.key] = item
+	return object, nil
+}
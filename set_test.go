@@ -0,0 +1,108 @@
+package main
+
+import (
+	"github.com/urfave/cli"
+	"reflect"
+	"testing"
+)
+
+func TestInferValue(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected interface{}
+	}{
+		{"true", true},
+		{"false", false},
+		{"null", nil},
+		{"3", float64(3)},
+		{"-1.5", -1.5},
+		{"1e3", float64(1000)},
+		{"0", float64(0)},
+		{"text", "text"},
+		{"yes", "yes"},
+		{"", ""},
+		{"01", "01"},
+		{"1.", "1."},
+		{".5", ".5"},
+		{"+1", "+1"},
+		{"0x10", "0x10"},
+		{"nan", "nan"},
+		{"NaN", "NaN"},
+		{"Inf", "Inf"},
+		{"-infinity", "-infinity"},
+		{"1e999", "1e999"},
+		{"1_000", "1_000"},
+	}
+	for _, test := range tests {
+		t.Run(test.text, func(t *testing.T) {
+			if value := inferValue(test.text); !reflect.DeepEqual(value, test.expected) {
+				t.Errorf("expected %#v, got %#v", test.expected, value)
+			}
+		})
+	}
+}
+
+func TestApplySets(t *testing.T) {
+	tests := []struct {
+		name     string
+		sets     []string
+		strings  []string
+		input    string
+		expected string
+	}{
+		{"override", []string{"a=2"}, nil, `{"a":1}`, `{"a":2}`},
+		{"nested", []string{"a.b.c=x"}, nil, `{"d":1}`, `{"a":{"b":{"c":"x"}},"d":1}`},
+		{"existing object", []string{"a.c=true"}, nil, `{"a":{"b":1}}`, `{"a":{"b":1,"c":true}}`},
+		{"types", []string{"n=1.5", "b=false", "z=null", "s=text"}, nil, `{}`, `{"b":false,"n":1.5,"s":"text","z":null}`},
+		{"string", nil, []string{"n=1.5", "b=false"}, `{}`, `{"b":"false","n":"1.5"}`},
+		{"set-string after set", []string{"v=1"}, []string{"v=1"}, `{}`, `{"v":"1"}`},
+		{"array index", []string{"items[0].name=a"}, nil, `{"items":[{"name":"x"}]}`, `{"items":[{"name":"a"}]}`},
+		{"array append", []string{"items[1]=b"}, nil, `{"items":["a"]}`, `{"items":["a","b"]}`},
+		{"new array", []string{"items[0]=a"}, nil, `{}`, `{"items":["a"]}`},
+		{"equals in the value", []string{"url=a=b"}, nil, `{}`, `{"url":"a=b"}`},
+		{"empty value", []string{"a="}, nil, `{"a":1}`, `{"a":""}`},
+		{"quoted key", []string{"labels['app.kubernetes.io/name']=web"}, nil, `{}`, `{"labels":{"app.kubernetes.io/name":"web"}}`},
+		{"empty document", []string{"a=1"}, nil, ``, `{"a":1}`},
+	}
+	defer func(sets, strings cli.StringSlice) {
+		setValues, setStringValues = sets, strings
+	}(setValues, setStringValues)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setValues, setStringValues = test.sets, test.strings
+			output, err := convertText(jsonFormat, jsonFormat, test.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if output != test.expected {
+				t.Errorf("expected %s, got %s", test.expected, output)
+			}
+		})
+	}
+}
+
+func TestApplySetsErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		set   string
+		input string
+	}{
+		{"no value", "a", `{}`},
+		{"no path", "=1", `{}`},
+		{"invalid path", "a..b=1", `{}`},
+		{"through a scalar", "a.b=1", `{"a":1}`},
+		{"through an array", "a.b=1", `{"a":[]}`},
+		{"index of an object", "a[0]=1", `{"a":{}}`},
+		{"index beyond the end", "a[2]=1", `{"a":["x"]}`},
+		{"huge index", "a[999999999999]=1", `{}`},
+	}
+	defer func(sets cli.StringSlice) { setValues = sets }(setValues)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setValues = cli.StringSlice{test.set}
+			if _, err := convertText(jsonFormat, jsonFormat, test.input); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/urfave/cli"
+	"io"
+)
+
+var stream bool
+
+var streamFlag = cli.BoolFlag{
+	Name:        "stream",
+	Usage:       "convert and write one document at a time, a top-level JSON array is streamed element by element",
+	Destination: &stream,
+}
+
+// streamDecoder decodes the documents read from r one at a time,
+// calling document with each of them.
+type streamDecoder func(r io.Reader, document func(interface{}) error) error
+
+// streamYAML decodes the YAML documents one at a time, recording their
+// key order with --preserve-order.
+func streamYAML(r io.Reader, document func(interface{}) error) error {
+	decode := decodeYAML
+	if preserveOrder {
+		decode = decodeOrderedYAML
+	}
+	reader := newYAMLReader(r)
+	for {
+		content, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := checkStrictYAML(content); err != nil {
+			return yamlError(err, reader.start)
+		}
+		var object interface{}
+		if err := decode(content, &object); err != nil {
+			return yamlError(err, reader.start)
+		}
+		if object == nil {
+			continue
+		}
+		if err := document(object); err != nil {
+			return err
+		}
+	}
+}
+
+func streamJSON(r io.Reader, document func(interface{}) error) error {
+	buffered := bufio.NewReader(r)
+	first, err := peekNonSpace(buffered)
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	decoder := newJSONDecoder(buffered)
+
+	if first == '[' {
+		if _, err := decoder.Token(); err != nil {
+			return err
+		}
+		for decoder.More() {
+			var object interface{}
+			if err := decoder.Decode(&object); err != nil {
+				return err
+			}
+			if err := document(preserveNumbers(object)); err != nil {
+				return err
+			}
+		}
+		_, err := decoder.Token()
+		return err
+	}
+
+	for {
+		var object interface{}
+		err := decoder.Decode(&object)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := document(preserveNumbers(object)); err != nil {
+			return err
+		}
+	}
+}
+
+// peekNonSpace skips the leading white space and returns the next byte without consuming it.
+func peekNonSpace(r *bufio.Reader) (byte, error) {
+	for {
+		c, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		if c != ' ' && c != '\t' && c != '\r' && c != '\n' {
+			return c, r.UnreadByte()
+		}
+	}
+}
+
+// transformStream converts the inputs one document at a time, writing
+// each converted document as soon as it is ready.
+func transformStream(paths []string, from, to format) error {
+	if from.stream == nil {
+		return usageError("--stream is only supported for YAML and JSON input")
+	}
+	if inputTar {
+		return usageError("--stream cannot be combined with --input-tar")
+	}
+	written := 0
+	return writeStream(outputPath, func(w io.Writer) error {
+		for _, path := range paths {
+			input, err := openInput(path)
+			if err != nil {
+				return err
+			}
+			input = progress.reader(path, input)
+			err = from.stream(input, func(object interface{}) error {
+				if err := checkDepth(path, object); err != nil {
+					return err
+				}
+				if err := validateSchema(path, object); err != nil {
+					return err
+				}
+				object, err := applyPatch(object)
+				if err != nil {
+					return err
+				}
+				document, err := render(object, to.marshal)
+				if err != nil || document == nil {
+					return err
+				}
+				if written > 0 {
+					if _, err := io.WriteString(w, to.documentSeparator()); err != nil {
+						return err
+					}
+				}
+				written++
+				_, err = w.Write(document)
+				return err
+			})
+			input.Close()
+			if err != nil {
+				return fmt.Errorf("cannot convert %s: %v", inputName(path), err)
+			}
+			progress.fileDone()
+		}
+		if written == 0 {
+			return emptyResult()
+		}
+		return nil
+	})
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestStreamYAML(t *testing.T) {
+	tests := []struct {
+		name     string
+		order    bool
+		input    string
+		expected []string
+	}{
+		{"documents", false, "b: 1\na: 2\n---\nz: 1\n", []string{`{"a":2,"b":1}`, `{"z":1}`}},
+		{"empty documents skipped", false, "---\n---\na: 1\n", []string{`{"a":1}`}},
+		{"preserved order", true, "b: 1\na: 2\n---\nz: 1\nx: {d: 1, c: 2}\n", []string{`{"b":1,"a":2}`, `{"z":1,"x":{"d":1,"c":2}}`}},
+		{"preserved order in arrays", true, "- b: 1\n  a: 2\n", []string{`[{"b":1,"a":2}]`}},
+	}
+	defer func(order bool) { preserveOrder = order }(preserveOrder)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			preserveOrder = test.order
+			var documents []string
+			err := streamYAML(strings.NewReader(test.input), func(object interface{}) error {
+				output, err := render(object, marshalJSON)
+				documents = append(documents, string(output))
+				return err
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(documents, test.expected) {
+				t.Errorf("expected %q, got %q", test.expected, documents)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+var (
+	stripNulls bool
+	stripEmpty bool
+)
+
+// stripObject removes the null entries of the objects of the value with
+// --strip-nulls, and the empty object and array entries with --strip-empty,
+// in place. An object emptied by the removals is removed in turn with
+// --strip-empty. The array elements are kept, as their index matters.
+func stripObject(value interface{}) {
+	if !stripNulls && !stripEmpty {
+		return
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			stripObject(item)
+			if stripped(item) {
+				delete(v, key)
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			stripObject(item)
+		}
+	case jsonpathResults:
+		for _, item := range v {
+			stripObject(item)
+		}
+	}
+}
+
+func stripped(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return stripNulls
+	case map[string]interface{}:
+		return stripEmpty && len(v) == 0
+	case []interface{}:
+		return stripEmpty && len(v) == 0
+	}
+	return false
+}
@@ -0,0 +1,36 @@
+package main
+
+import "testing"
+
+func TestStripObject(t *testing.T) {
+	tests := []struct {
+		name     string
+		nulls    bool
+		empty    bool
+		input    string
+		expected string
+	}{
+		{"disabled", false, false, `{"a":null,"b":{}}`, `{"a":null,"b":{}}`},
+		{"nulls", true, false, `{"a":null,"b":1}`, `{"b":1}`},
+		{"nested nulls", true, false, `{"a":{"b":null,"c":{"d":null}},"e":[{"f":null,"g":1}]}`, `{"a":{"c":{}},"e":[{"g":1}]}`},
+		{"null array elements kept", true, false, `{"a":[null,1,null]}`, `{"a":[null,1,null]}`},
+		{"falsy values kept", true, true, `{"a":false,"b":0,"c":"","d":null}`, `{"a":false,"b":0,"c":""}`},
+		{"empty", false, true, `{"a":{},"b":[],"c":null,"d":{"e":[]}}`, `{"c":null}`},
+		{"emptied by the nulls", true, true, `{"a":{"b":null},"c":{"d":{"e":null}},"f":1}`, `{"f":1}`},
+		{"empty array elements kept", false, true, `{"a":[{},[]]}`, `{"a":[{},[]]}`},
+		{"root object", true, true, `{"a":null}`, `{}`},
+	}
+	defer func(nulls, empty bool) { stripNulls, stripEmpty = nulls, empty }(stripNulls, stripEmpty)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stripNulls, stripEmpty = test.nulls, test.empty
+			output, err := convertText(jsonFormat, jsonFormat, test.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if output != test.expected {
+				t.Errorf("expected %s, got %s", test.expected, output)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/urfave/cli"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+var tableColumns string
+
+var tableFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:        "columns",
+		Usage:       "the comma-separated dotted paths of the columns, e.g. metadata.name,spec.replicas (the top-level keys otherwise)",
+		Destination: &tableColumns,
+	},
+}
+
+var tableFormat = format{marshal: marshalTable, separator: "\n"}
+
+// tableCellReplacer keeps the cells on a single line of their column.
+var tableCellReplacer = strings.NewReplacer("\t", " ", "\r", " ", "\n", " ")
+
+// marshalTable writes an array of objects as a table aligned like kubectl's,
+// with a header row of the column paths.
+func marshalTable(object interface{}) ([]byte, error) {
+	items, ok := object.([]interface{})
+	if !ok {
+		return nil, errors.New("table output requires a top-level array of objects")
+	}
+	for i, item := range items {
+		if _, ok := item.(map[string]interface{}); !ok {
+			return nil, fmt.Errorf("table output requires a top-level array of objects, element %d is not an object", i)
+		}
+	}
+	columns, paths, err := tableColumnPaths(items)
+	if err != nil {
+		return nil, err
+	}
+
+	var output bytes.Buffer
+	writer := tabwriter.NewWriter(&output, 0, 0, 3, ' ', 0)
+	header := make([]string, len(columns))
+	for i, column := range columns {
+		header[i] = strings.ToUpper(column)
+	}
+	fmt.Fprintln(writer, strings.Join(header, "\t"))
+	for _, item := range items {
+		cells := make([]string, len(paths))
+		for i, path := range paths {
+			value, found := lookupSegments(item, path)
+			if !found {
+				continue
+			}
+			cell, err := csvField(value)
+			if err != nil {
+				return nil, err
+			}
+			cells[i] = tableCellReplacer.Replace(cell)
+		}
+		fmt.Fprintln(writer, strings.Join(cells, "\t"))
+	}
+	if err := writer.Flush(); err != nil {
+		return nil, err
+	}
+	lines := strings.Split(strings.TrimSuffix(output.String(), "\n"), "\n")
+	for i, line := range lines {
+		// the empty cells of the last columns leave padding behind
+		lines[i] = strings.TrimRight(line, " ")
+	}
+	return []byte(strings.Join(lines, "\n")), nil
+}
+
+// tableColumnPaths returns the --columns and their parsed paths, or the sorted
+// union of the keys of the items, which are looked up as they are, dots included.
+func tableColumnPaths(items []interface{}) ([]string, [][]selectSegment, error) {
+	if tableColumns != "" {
+		columns := strings.Split(tableColumns, ",")
+		paths := make([][]selectSegment, len(columns))
+		for i, column := range columns {
+			columns[i] = strings.TrimSpace(column)
+			segments, err := parseSelectPath(columns[i])
+			if err != nil {
+				return nil, nil, usageError(fmt.Sprintf("invalid column %q: %v", columns[i], err))
+			}
+			paths[i] = segments
+		}
+		return columns, paths, nil
+	}
+	keys := map[string]bool{}
+	for _, item := range items {
+		for key := range item.(map[string]interface{}) {
+			keys[key] = true
+		}
+	}
+	columns := make([]string, 0, len(keys))
+	for key := range keys {
+		columns = append(columns, key)
+	}
+	sort.Strings(columns)
+	paths := make([][]selectSegment, len(columns))
+	for i, column := range columns {
+		paths[i] = []selectSegment{{key: column}}
+	}
+	return columns, paths, nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import "testing"
+
+func TestMarshalTable(t *testing.T) {
+	tests := []struct {
+		name     string
+		columns  string
+		input    string
+		expected string
+	}{
+		{"top-level keys", "", `[{"name":"a","replicas":1},{"name":"b"}]`,
+			"NAME   REPLICAS\na      1\nb"},
+		{"dotted and bracketed keys", "", `[{"a.b":1,"c[":2,"d":3}]`,
+			"A.B   C[   D\n1     2    3"},
+		{"columns", "metadata.name, spec.replicas", `[{"metadata":{"name":"web"},"spec":{"replicas":2}}]`,
+			"METADATA.NAME   SPEC.REPLICAS\nweb             2"},
+		{"bracketed column", "labels['app.kubernetes.io/name']", `[{"labels":{"app.kubernetes.io/name":"web"}}]`,
+			"LABELS['APP.KUBERNETES.IO/NAME']\nweb"},
+		{"objects as JSON", "", `[{"a":{"b":1},"c":"two\nlines"}]`,
+			"A         C\n{\"b\":1}   two lines"},
+	}
+	defer func(columns string) { tableColumns = columns }(tableColumns)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tableColumns = test.columns
+			output, err := convertText(jsonFormat, tableFormat, test.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if output != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, output)
+			}
+		})
+	}
+}
+
+func TestMarshalTableErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		columns string
+		input   string
+		err     string
+	}{
+		{"not an array", "", `{"a":1}`, "table output requires a top-level array of objects"},
+		{"not an object", "", `[{"a":1},2]`, "table output requires a top-level array of objects, element 1 is not an object"},
+		{"invalid column", "a,c[", `[{"a":1}]`, `ERROR: invalid column "c[": malformed index "["`},
+	}
+	defer func(columns string) { tableColumns = columns }(tableColumns)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tableColumns = test.columns
+			_, err := convertText(jsonFormat, tableFormat, test.input)
+			if err == nil || err.Error() != test.err {
+				t.Errorf("expected the error %q, got %v", test.err, err)
+			}
+		})
+	}
+}
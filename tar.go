@@ -0,0 +1,83 @@
+package main
+
+import (
+	"archive/tar"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"io"
+	"io/ioutil"
+	"path"
+	"strings"
+)
+
+var inputTar bool
+
+// tarExtensions are the extensions of the archive entries that are converted.
+var tarExtensions = map[string]bool{".yaml": true, ".yml": true, ".json": true}
+
+// unmarshalTar decodes the YAML and JSON files of the tar archive into one
+// value, the array of the files when there are several of them, for the
+// options that convert every input as a whole.
+func unmarshalTar(inputPath string, unmarshal unmarshaller) (interface{}, error) {
+	objects, err := unmarshalTarEntries(inputPath, unmarshal)
+	if err != nil {
+		return nil, err
+	}
+	if len(objects) == 0 {
+		return nil, nil
+	} else if len(objects) == 1 {
+		return objects[0], nil
+	} else {
+		return objects, nil
+	}
+}
+
+// unmarshalTarEntries decodes the YAML and JSON files of the tar archive,
+// nested directories included, each file being a document of its own.
+func unmarshalTarEntries(inputPath string, unmarshal unmarshaller) ([]interface{}, error) {
+	input, err := openInput(inputPath)
+	if err != nil {
+		return nil, err
+	}
+	defer input.Close()
+
+	var objects []interface{}
+	archive := tar.NewReader(input)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot read the tar archive %s: %v", inputName(inputPath), err)
+		}
+		if header.Typeflag != tar.TypeReg || !tarExtensions[strings.ToLower(path.Ext(header.Name))] {
+			logrus.Debugf("skipping the tar entry %v", header.Name)
+			continue
+		}
+		content, err := ioutil.ReadAll(archive)
+		if err != nil {
+			return nil, err
+		}
+		name := inputName(inputPath) + ":" + header.Name
+		logrus.Debugf("decoding the tar entry %v", header.Name)
+		object, err := unmarshal(content)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse %s: %v", name, err)
+		}
+		if err := checkDepth(name, object); err != nil {
+			return nil, err
+		}
+		if err := validateSchema(name, object); err != nil {
+			return nil, err
+		}
+		if object == nil {
+			continue
+		}
+		if object, err = applyPatch(object); err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+		objects = append(objects, object)
+	}
+	return objects, nil
+}
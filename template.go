@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/ghodss/yaml"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+var (
+	templateText   string
+	templateFile   string
+	rawInput       bool
+	outputTemplate *template.Template
+)
+
+// templateFuncs are the helper functions available to the --template.
+var templateFuncs = template.FuncMap{
+	"toJson": func(value interface{}) (string, error) {
+		encoded, err := json.Marshal(value)
+		return string(encoded), err
+	},
+	"toPrettyJson": func(value interface{}) (string, error) {
+		encoded, err := json.MarshalIndent(value, "", "  ")
+		return string(encoded), err
+	},
+	"toYaml": func(value interface{}) (string, error) {
+		encoded, err := yaml.Marshal(value)
+		return strings.TrimSuffix(string(encoded), "\n"), err
+	},
+	"b64enc": func(text string) string {
+		return base64.StdEncoding.EncodeToString([]byte(text))
+	},
+	"b64dec": func(text string) (string, error) {
+		decoded, err := base64.StdEncoding.DecodeString(text)
+		return string(decoded), err
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"trim":  strings.TrimSpace,
+	"quote": func(value interface{}) string {
+		return strconv.Quote(templateString(value))
+	},
+	"join": func(separator string, items []interface{}) string {
+		values := make([]string, len(items))
+		for i, item := range items {
+			values[i] = templateString(item)
+		}
+		return strings.Join(values, separator)
+	},
+	"indent": func(spaces int, text string) string {
+		padding := strings.Repeat(" ", spaces)
+		return padding + strings.Replace(text, "\n", "\n"+padding, -1)
+	},
+	"default": func(fallback, value interface{}) interface{} {
+		if value == nil || value == "" {
+			return fallback
+		}
+		return value
+	},
+}
+
+// parseTemplate parses the --template or --template-file, which replaces the
+// marshaller of the output format.
+func parseTemplate() error {
+	if templateFile != "" {
+		if templateText != "" {
+			return usageError("--template and --template-file cannot be combined")
+		}
+		content, err := ioutil.ReadFile(templateFile)
+		if err != nil {
+			return err
+		}
+		templateText = string(content)
+	}
+	if templateText == "" {
+		if rawInput {
+			return usageError("--raw-input requires --template or --template-file")
+		}
+		return nil
+	}
+	if rawInput && (stream || inputTar) {
+		return usageError("--raw-input cannot be combined with --stream or --input-tar")
+	}
+	if countResults {
+		return usageError("--template cannot be combined with --count")
+	}
+	parsed, err := template.New("output").Funcs(templateFuncs).Option("missingkey=zero").Parse(templateText)
+	if err != nil {
+		return usageError("invalid template: " + err.Error())
+	}
+	outputTemplate = parsed
+	return nil
+}
+
+// executeTemplate renders the object through the --template.
+func executeTemplate(object interface{}) ([]byte, error) {
+	if results, ok := object.(jsonpathResults); ok {
+		object = []interface{}(results)
+	}
+	var output bytes.Buffer
+	if err := outputTemplate.Execute(&output, object); err != nil {
+		return nil, err
+	}
+	return output.Bytes(), nil
+}
+
+// templateString formats the scalars like the text output, and the rest as JSON.
+func templateString(value interface{}) string {
+	switch value.(type) {
+	case map[string]interface{}, []interface{}:
+		encoded, _ := json.Marshal(value)
+		return string(encoded)
+	}
+	return fmt.Sprint(value)
+}
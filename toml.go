@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"github.com/BurntSushi/toml"
+	"math"
+	"time"
+)
+
+var tomlFormat = format{unmarshal: unmarshalTOML, marshal: marshalTOML, separator: "\n"}
+
+func unmarshalTOML(input []byte) (interface{}, error) {
+	var object map[string]interface{}
+	if err := toml.Unmarshal(input, &object); err != nil {
+		return nil, err
+	}
+	if len(object) == 0 {
+		return nil, nil
+	}
+	return tomlToJSON(object), nil
+}
+
+// tomlLocalLayouts are the layouts of the TOML local dates and times, which
+// the decoder gives these locations, as they have no time zone to write.
+var tomlLocalLayouts = map[string]string{
+	"datetime-local": "2006-01-02T15:04:05.999999999",
+	"date-local":     "2006-01-02",
+	"time-local":     "15:04:05.999999999",
+}
+
+// tomlToJSON replaces the TOML date and time values, which have no
+// counterpart in JSON, with their RFC3339 representation, or the local
+// date and time ones with theirs, e.g. 1979-05-27 or 07:32:00. The integers,
+// decoded as int64, become float64 like the JSON ones, unless --preserve-int.
+func tomlToJSON(value interface{}) interface{} {
+	switch v := value.(type) {
+	case int64:
+		if preserveInt {
+			return preservedInt(v)
+		}
+		return float64(v)
+	case time.Time:
+		if layout, ok := tomlLocalLayouts[v.Location().String()]; ok {
+			return v.Format(layout)
+		}
+		return v.Format(time.RFC3339Nano)
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = tomlToJSON(item)
+		}
+	case []map[string]interface{}:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = tomlToJSON(item)
+		}
+		return items
+	case []interface{}:
+		for i, item := range v {
+			v[i] = tomlToJSON(item)
+		}
+	}
+	return value
+}
+
+func marshalTOML(object interface{}) ([]byte, error) {
+	table, ok := object.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("TOML output requires a top-level object")
+	}
+	var output bytes.Buffer
+	if err := toml.NewEncoder(&output).Encode(jsonToTOML(table)); err != nil {
+		return nil, err
+	}
+	return output.Bytes(), nil
+}
+
+// jsonToTOML turns the whole float64 numbers produced by the JSON and YAML
+// decoders back into integers, so that they are not written as 3.0 in TOML.
+func jsonToTOML(value interface{}) interface{} {
+	switch v := value.(type) {
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+			return int64(v)
+		}
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = jsonToTOML(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = jsonToTOML(item)
+		}
+	}
+	return value
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"github.com/itchyny/gojq"
+	"testing"
+)
+
+func TestMarshalTOML(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"scalars", `{"name":"2fy","enabled":true,"ratio":0.5}`, "enabled = true\nname = \"2fy\"\nratio = 0.5\n"},
+		{"integers", `{"port":8080,"negative":-3}`, "negative = -3\nport = 8080\n"},
+		{"array", `{"ports":[80,443]}`, "ports = [80, 443]\n"},
+		{"table", `{"server":{"host":"localhost"}}`, "[server]\n  host = \"localhost\"\n"},
+		{"array of tables", `{"servers":[{"host":"a"},{"host":"b"}]}`, "[[servers]]\n  host = \"a\"\n\n[[servers]]\n  host = \"b\"\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output, err := convertText(jsonFormat, tomlFormat, test.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if output != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, output)
+			}
+		})
+	}
+}
+
+func TestMarshalTOMLTopLevel(t *testing.T) {
+	for _, input := range []string{`[1,2]`, `"text"`, `3`, `true`} {
+		t.Run(input, func(t *testing.T) {
+			_, err := convertText(jsonFormat, tomlFormat, input)
+			if err == nil || err.Error() != "TOML output requires a top-level object" {
+				t.Errorf("expected the top-level object error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestYAMLToTOML(t *testing.T) {
+	tests := []struct {
+		name     string
+		jsonpath string
+		input    string
+		expected string
+		err      string
+	}{
+		{"object", "", "name: app\nreplicas: 3\n", "name = \"app\"\nreplicas = 3\n", ""},
+		{"selected object", "{.spec}", "kind: Deployment\nspec:\n  replicas: 3\n", "replicas = 3\n", ""},
+		{"selected array", "{.items}", "items: [1, 2]\n", "", "TOML output requires a top-level object"},
+		{"top-level array", "", "- 1\n- 2\n", "", "TOML output requires a top-level object"},
+	}
+	defer func(templates []string) { jsonpathTemplates = templates }(jsonpathTemplates)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			jsonpathTemplates = nil
+			if test.jsonpath != "" {
+				jsonpathTemplates = []string{test.jsonpath}
+			}
+			output, err := convertText(yamlFormat, tomlFormat, test.input)
+			if test.err != "" {
+				if err == nil || err.Error() != test.err {
+					t.Errorf("expected the error %q, got %v", test.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if output != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, output)
+			}
+		})
+	}
+}
+
+func TestTOMLIntegers(t *testing.T) {
+	tests := []struct {
+		name       string
+		preserve   bool
+		expression string
+		input      string
+		expected   string
+	}{
+		{"jq arithmetic", false, ".a + 1", "a = 1\n", "2"},
+		{"jq comparison", false, ".a > 1", "a = 2\n", "true"},
+		{"jq preserved", true, ".a + 1", "a = 9007199254740993\n", "9007199254740994"},
+		{"nested", false, ".b.c * 2", "[b]\nc = 21\n", "42"},
+		{"array", false, ".ports | add", "ports = [80, 443]\n", "523"},
+	}
+	defer func(preserve bool, expression string, code *gojq.Code) {
+		preserveInt, jqExpression, jqCode = preserve, expression, code
+	}(preserveInt, jqExpression, jqCode)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			preserveInt = test.preserve
+			code, err := parseJQ(test.expression)
+			if err != nil {
+				t.Fatal(err)
+			}
+			jqExpression, jqCode = test.expression, code
+			output, err := convertText(tomlFormat, jsonFormat, test.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if output != test.expected {
+				t.Errorf("expected %s, got %s", test.expected, output)
+			}
+		})
+	}
+}
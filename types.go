@@ -0,0 +1,43 @@
+package main
+
+import "fmt"
+
+var withTypes bool
+
+// annotateTypes wraps the result, or each of several results, into an object of
+// its JSON type and its value, e.g. {"type": "string", "value": "nginx"}, to see
+// what a JSONPath template actually matched.
+func annotateTypes(result interface{}) interface{} {
+	if results, ok := result.(jsonpathResults); ok {
+		annotated := make(jsonpathResults, len(results))
+		for i, item := range results {
+			annotated[i] = annotateType(item)
+		}
+		return annotated
+	}
+	return annotateType(result)
+}
+
+func annotateType(value interface{}) interface{} {
+	return map[string]interface{}{"type": jsonType(value), "value": value}
+}
+
+// jsonType is the name of the JSON type of the value, or of its Go type
+// when it is not one the decoders produce.
+func jsonType(value interface{}) string {
+	switch value.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case float64, int, int64:
+		return "number"
+	case bool:
+		return "boolean"
+	case nil:
+		return "null"
+	}
+	return fmt.Sprintf("%T", value)
+}
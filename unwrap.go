@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+)
+
+// unwrapFormat decodes the documents read like from, replacing the
+// Kubernetes List manifests, of kind List or any *List such as PodList,
+// with their items. The other documents are kept as they are.
+func unwrapFormat(from format) format {
+	return format{
+		unmarshal: func(input []byte) (interface{}, error) {
+			object, err := from.unmarshal(input)
+			if err != nil || object == nil {
+				return object, err
+			}
+			documents, ok := object.([]interface{})
+			if !ok {
+				if items, ok := listItems(object); ok {
+					return items, nil
+				}
+				return object, nil
+			}
+			var unwrapped []interface{}
+			for _, document := range documents {
+				if items, ok := listItems(document); ok {
+					unwrapped = append(unwrapped, items...)
+				} else {
+					unwrapped = append(unwrapped, document)
+				}
+			}
+			return unwrapped, nil
+		},
+	}
+}
+
+// listItems returns the items of a List manifest.
+func listItems(object interface{}) ([]interface{}, bool) {
+	manifest, ok := object.(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	kind, _ := manifest["kind"].(string)
+	if !strings.HasSuffix(kind, "List") {
+		return nil, false
+	}
+	items, ok := manifest["items"].([]interface{})
+	if !ok && manifest["items"] != nil {
+		return nil, false
+	}
+	return items, true
+}
+
+// marshalDocuments writes the elements of an array as the documents of
+// a multi-document stream, and any other value as a single document.
+func marshalDocuments(to format) marshaller {
+	return func(object interface{}) ([]byte, error) {
+		items, ok := object.([]interface{})
+		if !ok {
+			return to.marshal(object)
+		}
+		documents := make([][]byte, len(items))
+		for i, item := range items {
+			document, err := to.marshal(item)
+			if err != nil {
+				return nil, err
+			}
+			documents[i] = document
+		}
+		return bytes.Join(documents, []byte(to.documentSeparator())), nil
+	}
+}
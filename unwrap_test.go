@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"testing"
+)
+
+func TestUnwrapPodList(t *testing.T) {
+	input, err := ioutil.ReadFile("testdata/podlist.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	object, err := unwrapFormat(yamlFormat).unmarshal(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	items, ok := object.([]interface{})
+	if !ok || len(items) != 2 {
+		t.Fatalf("expected the 2 pods, got %#v", object)
+	}
+	for i, name := range []string{"web-0", "web-1"} {
+		pod := items[i].(map[string]interface{})
+		if pod["kind"] != "Pod" || pod["metadata"].(map[string]interface{})["name"] != name {
+			t.Errorf("expected the pod %s, got %#v", name, pod)
+		}
+	}
+
+	output, err := render(object, marshalDocuments(yamlFormat))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pod := "apiVersion: v1\nkind: Pod\nmetadata:\n  name: %s\n  namespace: default\nspec:\n  containers:\n  - image: nginx:1.25\n    name: nginx\n"
+	expected := fmt.Sprintf(pod, "web-0") + yamlDocumentMarker + fmt.Sprintf(pod, "web-1")
+	if string(output) != expected {
+		t.Errorf("expected %q, got %q", expected, output)
+	}
+}
+
+func TestUnwrapFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected interface{}
+	}{
+		{"list", "kind: List\nitems:\n- a: 1\n- b: 2\n", []interface{}{
+			map[string]interface{}{"a": float64(1)},
+			map[string]interface{}{"b": float64(2)},
+		}},
+		{"empty list", "kind: ServiceList\nitems: []\n", []interface{}{}},
+		{"list without items", "kind: List\nitems: null\n", []interface{}(nil)},
+		{"not a list", "kind: Pod\nitems: [1]\n", map[string]interface{}{"kind": "Pod", "items": []interface{}{float64(1)}}},
+		{"items of another type", "kind: List\nitems: 1\n", map[string]interface{}{"kind": "List", "items": float64(1)}},
+		{"documents", "kind: List\nitems: [1, 2]\n---\nkind: Pod\n", []interface{}{
+			float64(1), float64(2), map[string]interface{}{"kind": "Pod"},
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			object, err := unwrapFormat(yamlFormat).unmarshal([]byte(test.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(object, test.expected) {
+				t.Errorf("expected %#v, got %#v", test.expected, object)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var httpTimeout = 30 * time.Second
+
+var (
+	httpRetries    int
+	httpRetryDelay = time.Second
+)
+
+// httpClient fetches the URL inputs, following the redirects.
+var httpClient = &http.Client{}
+
+// isURL reports whether the input path is an HTTP or HTTPS URL.
+func isURL(inputPath string) bool {
+	return strings.HasPrefix(inputPath, "http://") || strings.HasPrefix(inputPath, "https://")
+}
+
+// openURL fetches the body of the URL input within the --timeout, retrying
+// the connection errors and the 5xx responses --retry times with a backoff.
+func openURL(inputURL string) (io.ReadCloser, error) {
+	logrus.Debugf("input URL: %v", inputURL)
+	httpClient.Timeout = httpTimeout
+	delay := httpRetryDelay
+	for attempt := 1; ; attempt++ {
+		body, retryable, err := fetchURL(inputURL)
+		if err == nil || !retryable || attempt > httpRetries {
+			return body, err
+		}
+		logrus.Debugf("retrying %v in %v, retry %d/%d: %v", inputURL, delay, attempt, httpRetries, err)
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// fetchURL fetches the URL once, reporting whether the error is worth a retry.
+func fetchURL(inputURL string) (io.ReadCloser, bool, error) {
+	start := time.Now()
+	response, err := httpClient.Get(inputURL)
+	if err != nil {
+		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+			return nil, true, fmt.Errorf("cannot fetch %s: timed out after %v", inputURL, time.Since(start).Round(time.Millisecond))
+		}
+		return nil, true, fmt.Errorf("cannot fetch %s: %v", inputURL, err)
+	}
+	if response.StatusCode != http.StatusOK {
+		response.Body.Close()
+		return nil, response.StatusCode >= 500, fmt.Errorf("cannot fetch %s: %s", inputURL, response.Status)
+	}
+	return response.Body, false, nil
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+var verifyOutput bool
+
+// verifyFormat is the output format, whose decoder --verify reads the output back with.
+var verifyFormat format
+
+func setupVerify(to format) error {
+	if !verifyOutput {
+		return nil
+	}
+	if to.unmarshal == nil {
+		return usageError("--verify cannot be used with an output format that cannot be read back")
+	}
+	verifyFormat = to
+	return nil
+}
+
+// verifyDocument decodes the marshalled output with the decoder of the output
+// format and checks that it is the value that was marshalled. The formats
+// that only have strings, like CSV or dotenv, compare the other scalars by
+// their text, the other ones must read back the same types, and the XML
+// output is compared below its --root element.
+func verifyDocument(object interface{}, output []byte) error {
+	if verifyFormat.unmarshal == nil {
+		return nil
+	}
+	decoded, err := verifyFormat.unmarshal(output)
+	if err != nil {
+		return fmt.Errorf("--verify: the output cannot be read back: %v", err)
+	}
+	expected, err := verifyValue(object)
+	if err != nil {
+		return err
+	}
+	actual, err := verifyValue(decoded)
+	if err != nil {
+		return err
+	}
+	if root, ok := actual.(map[string]interface{}); ok && xmlRoot != "" && len(root) == 1 {
+		if value, ok := root[xmlRoot]; ok {
+			actual = value
+		}
+	}
+	if path, ok := compareVerified(expected, actual, "", verifyFormat.stringValues); !ok {
+		if path == "" {
+			path = "the top level"
+		}
+		return fmt.Errorf("--verify: the output reads back differently at %s", path)
+	}
+	return nil
+}
+
+// verifyValue normalizes the value through JSON, so that the numbers
+// of the different decoders are all float64.
+func verifyValue(value interface{}) (interface{}, error) {
+	if documents, ok := value.(yamlNodes); ok {
+		values := make([]interface{}, len(documents))
+		for i, document := range documents {
+			if err := document.Decode(&values[i]); err != nil {
+				return nil, err
+			}
+			values[i] = toOrderedObject(values[i])
+		}
+		value = values
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("--verify: %v", err)
+	}
+	var normalized interface{}
+	if err := json.Unmarshal(encoded, &normalized); err != nil {
+		return nil, fmt.Errorf("--verify: %v", err)
+	}
+	return normalized, nil
+}
+
+// compareVerified compares the values, returning the path of the first
+// difference, e.g. items[0].name. With stringValues, a string read back
+// matches the text of any other value.
+func compareVerified(expected, actual interface{}, path string, stringValues bool) (string, bool) {
+	if text, ok := actual.(string); ok && stringValues {
+		if _, ok := expected.(string); !ok {
+			// CSV embeds the objects and arrays as JSON too
+			field, err := csvField(expected)
+			return path, err == nil && field == text
+		}
+	}
+	switch e := expected.(type) {
+	case map[string]interface{}:
+		a, ok := actual.(map[string]interface{})
+		if !ok {
+			return path, false
+		}
+		keys := make([]string, 0, len(e)+len(a))
+		for key := range e {
+			keys = append(keys, key)
+		}
+		for key := range a {
+			if _, ok := e[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			child := key
+			if path != "" {
+				child = path + "." + key
+			}
+			if path, ok := compareVerified(e[key], a[key], child, stringValues); !ok {
+				return path, false
+			}
+		}
+		return "", true
+	case []interface{}:
+		a, ok := actual.([]interface{})
+		if !ok || len(a) != len(e) {
+			return path, false
+		}
+		for i := range e {
+			if path, ok := compareVerified(e[i], a[i], path+"["+strconv.Itoa(i)+"]", stringValues); !ok {
+				return path, false
+			}
+		}
+		return "", true
+	}
+	return path, reflect.DeepEqual(expected, actual)
+}
@@ -0,0 +1,45 @@
+package main
+
+import "testing"
+
+func TestVerifyDocument(t *testing.T) {
+	tests := []struct {
+		name   string
+		to     format
+		object string
+		output string
+		err    string
+	}{
+		{"yaml", yamlFormat, `{"a":1,"b":["x"]}`, "a: 1\nb:\n- x\n", ""},
+		{"yaml string for a number", yamlFormat, `{"a":1}`, "a: \"1\"\n", "--verify: the output reads back differently at a"},
+		{"yaml number for a string", yamlFormat, `{"a":"1"}`, "a: 1\n", "--verify: the output reads back differently at a"},
+		{"yaml string for a boolean", yamlFormat, `{"a":[true]}`, "a:\n- \"true\"\n", "--verify: the output reads back differently at a[0]"},
+		{"json string for a number", jsonFormat, `{"a":1}`, `{"a":"1"}`, "--verify: the output reads back differently at a"},
+		{"toml string for a number", tomlFormat, `{"a":1}`, "a = \"1\"\n", "--verify: the output reads back differently at a"},
+		{"yaml different value", yamlFormat, `{"a":{"b":1}}`, "a:\n  b: 2\n", "--verify: the output reads back differently at a.b"},
+		{"csv numbers as text", csvFormat, `[{"a":1,"b":true}]`, "a,b\n1,true\n", ""},
+		{"env numbers as text", envFormat, `{"A":1}`, "A=1\n", ""},
+		{"csv different text", csvFormat, `[{"a":1}]`, "a\n2\n", "--verify: the output reads back differently at [0].a"},
+	}
+	defer func(to format, delimiter string) { verifyFormat, csvDelimiter = to, delimiter }(verifyFormat, csvDelimiter)
+	csvDelimiter = ","
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			verifyFormat = test.to
+			object, err := unmarshalJSON([]byte(test.object))
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = verifyDocument(object, []byte(test.output))
+			if test.err == "" {
+				if err != nil {
+					t.Errorf("unexpected error %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != test.err {
+				t.Errorf("expected the error %q, got %v", test.err, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+	"strconv"
+	"strings"
+)
+
+var whereExpressions cli.StringSlice
+
+// whereOperators are tried in order, the two-character ones first.
+var whereOperators = []string{"!=", ">=", "<=", "==", "=", ">", "<"}
+
+// wherePredicate compares the value at the dotted path of an element.
+type wherePredicate struct {
+	expression string
+	path       []selectSegment
+	operator   string
+	value      string
+}
+
+var wherePredicates []wherePredicate
+
+var fieldSelector string
+
+// fieldSelectors are the --field-selector requirements, the = and != predicates
+// of the kubectl syntax.
+var fieldSelectors []wherePredicate
+
+// parseWhere parses the --where expressions, like spec.replicas>1.
+func parseWhere() error {
+	wherePredicates = nil
+	for _, expression := range whereExpressions {
+		predicate, err := parseWherePredicate(expression)
+		if err != nil {
+			return usageError(fmt.Sprintf("invalid --where %q: %v", expression, err))
+		}
+		wherePredicates = append(wherePredicates, predicate)
+	}
+	return nil
+}
+
+// parseFieldSelector parses the comma-separated requirements of the
+// --field-selector, like status.phase=Running,spec.nodeName!=node1.
+func parseFieldSelector() error {
+	fieldSelectors = nil
+	if fieldSelector == "" {
+		return nil
+	}
+	for _, requirement := range strings.Split(fieldSelector, ",") {
+		predicate, err := parseWherePredicate(requirement)
+		if err != nil {
+			return usageError(fmt.Sprintf("invalid --field-selector %q: %v", requirement, err))
+		}
+		if predicate.operator != "=" && predicate.operator != "==" && predicate.operator != "!=" {
+			return usageError(fmt.Sprintf("invalid --field-selector %q: only =, == and != are supported", requirement))
+		}
+		fieldSelectors = append(fieldSelectors, predicate)
+	}
+	return nil
+}
+
+func parseWherePredicate(expression string) (wherePredicate, error) {
+	// keep the earliest operator, the two-character ones winning ties
+	i, operator := -1, ""
+	for _, candidate := range whereOperators {
+		if j := strings.Index(expression, candidate); j >= 0 && (i < 0 || j < i) {
+			i, operator = j, candidate
+		}
+	}
+	if i < 0 {
+		return wherePredicate{}, fmt.Errorf("expected field=value, field!=value, field>value or field<value")
+	}
+	field := strings.TrimSpace(expression[:i])
+	if field == "" {
+		return wherePredicate{}, fmt.Errorf("missing field before %q", operator)
+	}
+	path, err := parseSelectPath(field)
+	if err != nil {
+		return wherePredicate{}, err
+	}
+	return wherePredicate{
+		expression: expression,
+		path:       path,
+		operator:   operator,
+		value:      strings.TrimSpace(expression[i+len(operator):]),
+	}, nil
+}
+
+// filterWhere keeps the elements of the top-level array matching all the --where.
+func filterWhere(object interface{}) (interface{}, error) {
+	if len(wherePredicates) == 0 {
+		return object, nil
+	}
+	items, ok := object.([]interface{})
+	if !ok {
+		return nil, exitError(fmt.Sprintf("--where requires a top-level array, not %s", jsonKind(object)), exitFailure)
+	}
+	kept := []interface{}{}
+	for _, item := range items {
+		if matchesWhere(item, wherePredicates) {
+			kept = append(kept, item)
+		}
+	}
+	logrus.Debugf("--where kept %d of %d elements", len(kept), len(items))
+	return kept, nil
+}
+
+// filterFieldSelector keeps the elements matching the --field-selector of the
+// top-level array, or of the .items of a Kubernetes List.
+func filterFieldSelector(object interface{}) (interface{}, error) {
+	if len(fieldSelectors) == 0 {
+		return object, nil
+	}
+	items, ok := object.([]interface{})
+	list, isList := object.(map[string]interface{})
+	if isList {
+		items, ok = list["items"].([]interface{})
+	}
+	if !ok {
+		return nil, exitError(fmt.Sprintf("--field-selector requires a top-level array or an object with .items, not %s", jsonKind(object)), exitFailure)
+	}
+	kept := []interface{}{}
+	for _, item := range items {
+		if matchesFieldSelector(item) {
+			kept = append(kept, item)
+		}
+	}
+	logrus.Debugf("--field-selector kept %d of %d elements", len(kept), len(items))
+	if isList {
+		list["items"] = kept
+		return list, nil
+	}
+	return kept, nil
+}
+
+// matchesFieldSelector matches the requirements like the API server does,
+// a missing field being different from any value.
+func matchesFieldSelector(item interface{}) bool {
+	for _, requirement := range fieldSelectors {
+		value, found := lookupSegments(item, requirement.path)
+		if !found {
+			if requirement.operator == "!=" {
+				continue
+			}
+			return false
+		}
+		if !requirement.matches(value) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchesWhere(item interface{}, predicates []wherePredicate) bool {
+	for _, predicate := range predicates {
+		value, found := lookupSegments(item, predicate.path)
+		if !found || !predicate.matches(value) {
+			return false
+		}
+	}
+	return true
+}
+
+// matches compares the value numerically when both sides are numbers,
+// and as strings otherwise.
+func (p wherePredicate) matches(value interface{}) bool {
+	field, err := csvField(value)
+	if err != nil {
+		return false
+	}
+	comparison := strings.Compare(field, p.value)
+	if number, ok := jsonNumber(value); ok {
+		if expected, err := strconv.ParseFloat(p.value, 64); err == nil {
+			comparison = compareFloats(number, expected)
+		}
+	}
+	switch p.operator {
+	case "=", "==":
+		return comparison == 0
+	case "!=":
+		return comparison != 0
+	case ">":
+		return comparison > 0
+	case ">=":
+		return comparison >= 0
+	case "<":
+		return comparison < 0
+	default:
+		return comparison <= 0
+	}
+}
+
+func compareFloats(a, b float64) int {
+	if a < b {
+		return -1
+	} else if a > b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"github.com/urfave/cli"
+	"testing"
+)
+
+func TestFilterWhere(t *testing.T) {
+	input := `[{"name":"a","replicas":1,"spec":{"tier":"web"}},{"name":"b","replicas":3,"spec":{"tier":"db"}},{"name":"c","replicas":10}]`
+	tests := []struct {
+		name        string
+		expressions []string
+		expected    string
+	}{
+		{"equal", []string{"name=b"}, `[{"name":"b","replicas":3,"spec":{"tier":"db"}}]`},
+		{"double equal", []string{"name==b"}, `[{"name":"b","replicas":3,"spec":{"tier":"db"}}]`},
+		{"dotted path", []string{"spec.tier=web"}, `[{"name":"a","replicas":1,"spec":{"tier":"web"}}]`},
+		{"not equal", []string{"name!=b"}, `[{"name":"a","replicas":1,"spec":{"tier":"web"}},{"name":"c","replicas":10}]`},
+		{"missing field", []string{"spec.tier!=web"}, `[{"name":"b","replicas":3,"spec":{"tier":"db"}}]`},
+		{"numeric greater", []string{"replicas>2"}, `[{"name":"b","replicas":3,"spec":{"tier":"db"}},{"name":"c","replicas":10}]`},
+		{"numeric less or equal", []string{"replicas<=3"}, `[{"name":"a","replicas":1,"spec":{"tier":"web"}},{"name":"b","replicas":3,"spec":{"tier":"db"}}]`},
+		{"string comparison", []string{"name>a"}, `[{"name":"b","replicas":3,"spec":{"tier":"db"}},{"name":"c","replicas":10}]`},
+		{"spaces", []string{"name = c"}, `[{"name":"c","replicas":10}]`},
+		{"several", []string{"replicas>=1", "name<c"}, `[{"name":"a","replicas":1,"spec":{"tier":"web"}},{"name":"b","replicas":3,"spec":{"tier":"db"}}]`},
+		{"no match", []string{"name=z"}, `[]`},
+	}
+	defer func(expressions cli.StringSlice) {
+		whereExpressions = expressions
+		parseWhere()
+	}(whereExpressions)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			whereExpressions = test.expressions
+			if err := parseWhere(); err != nil {
+				t.Fatal(err)
+			}
+			output, err := convertText(jsonFormat, jsonFormat, input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if output != test.expected {
+				t.Errorf("expected %s, got %s", test.expected, output)
+			}
+		})
+	}
+}
+
+func TestParseWhereErrors(t *testing.T) {
+	tests := []string{"name", "=b", "a..b=1", "a[x]=1"}
+	defer func(expressions cli.StringSlice) {
+		whereExpressions = expressions
+		parseWhere()
+	}(whereExpressions)
+	for _, expression := range tests {
+		t.Run(expression, func(t *testing.T) {
+			whereExpressions = cli.StringSlice{expression}
+			if err := parseWhere(); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestFilterWhereNotArray(t *testing.T) {
+	defer func(expressions cli.StringSlice) {
+		whereExpressions = expressions
+		parseWhere()
+	}(whereExpressions)
+	whereExpressions = cli.StringSlice{"name=a"}
+	if err := parseWhere(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := convertText(jsonFormat, jsonFormat, `{"name":"a"}`); err == nil {
+		t.Error("expected an error for the top-level object")
+	}
+}
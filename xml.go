@@ -0,0 +1,217 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"github.com/urfave/cli"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	xmlAttributePrefix = "@"
+	xmlTextKey         = "#text"
+)
+
+var xmlFormat = format{unmarshal: unmarshalXML, marshal: marshalXML, separator: "\n", stringValues: true}
+
+// xmlNamePattern matches the element and attribute names that need no escaping.
+var xmlNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.:-]*$`)
+
+// xmlElement accumulates the attributes, children and text of an element
+// while its content is being decoded.
+type xmlElement struct {
+	name   string
+	fields map[string]interface{}
+	text   bytes.Buffer
+}
+
+func newXMLElement(start xml.StartElement) *xmlElement {
+	element := &xmlElement{name: start.Name.Local, fields: map[string]interface{}{}}
+	for _, attr := range start.Attr {
+		name := attr.Name.Local
+		if attr.Name.Space == "xmlns" {
+			name = "xmlns:" + name
+		}
+		element.fields[xmlAttributePrefix+name] = attr.Value
+	}
+	return element
+}
+
+// addChild stores the value of a child element, turning repeated
+// sibling elements into an array.
+func (e *xmlElement) addChild(name string, value interface{}) {
+	existing, ok := e.fields[name]
+	if !ok {
+		e.fields[name] = value
+		return
+	}
+	if items, ok := existing.([]interface{}); ok {
+		e.fields[name] = append(items, value)
+	} else {
+		e.fields[name] = []interface{}{existing, value}
+	}
+}
+
+// value returns the generic representation of the element: a plain string
+// for text-only elements, a map otherwise and nil for empty elements.
+func (e *xmlElement) value() interface{} {
+	text := strings.TrimSpace(e.text.String())
+	if len(e.fields) == 0 {
+		if text == "" {
+			return nil
+		}
+		return text
+	}
+	if text != "" {
+		e.fields[xmlTextKey] = text
+	}
+	return e.fields
+}
+
+// unmarshalXML decodes the input into a map keyed by the root element name.
+// Attributes are prefixed with '@' and text of elements having attributes
+// or children is stored under '#text'.
+func unmarshalXML(input []byte) (interface{}, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(input))
+	document := &xmlElement{fields: map[string]interface{}{}}
+	stack := []*xmlElement{document}
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			stack = append(stack, newXMLElement(t))
+		case xml.EndElement:
+			element := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			stack[len(stack)-1].addChild(element.name, element.value())
+		case xml.CharData:
+			stack[len(stack)-1].text.Write(t)
+		}
+	}
+	if len(document.fields) == 0 {
+		return nil, nil
+	}
+	return document.fields, nil
+}
+
+var xmlRoot string
+
+var xmlRootFlag = cli.StringFlag{
+	Name:        "root",
+	Usage:       "the name of the root element, required since JSON has none",
+	Destination: &xmlRoot,
+}
+
+// marshalXML writes the object as the --root element, with the keys
+// prefixed with @ as attributes, #text as the text and arrays as
+// repeated elements, the reverse of unmarshalXML.
+func marshalXML(object interface{}) ([]byte, error) {
+	if xmlRoot == "" {
+		return nil, usageError("XML output requires --root to name the root element")
+	}
+	if _, ok := object.([]interface{}); ok {
+		return nil, errors.New("XML output cannot have an array as the root element, select an object or wrap the array in one")
+	}
+	var output bytes.Buffer
+	encoder := xml.NewEncoder(&output)
+	encoder.Indent("", "  ")
+	if err := encodeXMLElement(encoder, xmlRoot, object); err != nil {
+		return nil, err
+	}
+	if err := encoder.Flush(); err != nil {
+		return nil, err
+	}
+	return output.Bytes(), nil
+}
+
+func encodeXMLElement(encoder *xml.Encoder, name string, value interface{}) error {
+	if !xmlNamePattern.MatchString(name) {
+		return fmt.Errorf("%q is not a valid XML element name", name)
+	}
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		if _, ok := value.([]interface{}); ok {
+			return fmt.Errorf("the element %q cannot be an array nested in an array", name)
+		}
+		return encodeXMLText(encoder, start, value)
+	}
+
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var children []string
+	for _, key := range keys {
+		if !strings.HasPrefix(key, xmlAttributePrefix) {
+			if key != xmlTextKey {
+				children = append(children, key)
+			}
+			continue
+		}
+		attr := strings.TrimPrefix(key, xmlAttributePrefix)
+		if !xmlNamePattern.MatchString(attr) {
+			return fmt.Errorf("%q is not a valid XML attribute name", attr)
+		}
+		switch object[key].(type) {
+		case map[string]interface{}, []interface{}:
+			return fmt.Errorf("the attribute %q of the element %q must be a scalar", attr, name)
+		}
+		text, _ := csvField(object[key])
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: attr}, Value: text})
+	}
+
+	if err := encoder.EncodeToken(start); err != nil {
+		return err
+	}
+	if text, ok := object[xmlTextKey]; ok {
+		field, err := csvField(text)
+		if err != nil {
+			return err
+		}
+		if err := encoder.EncodeToken(xml.CharData(field)); err != nil {
+			return err
+		}
+	}
+	for _, key := range children {
+		items, ok := object[key].([]interface{})
+		if !ok {
+			items = []interface{}{object[key]}
+		}
+		for _, item := range items {
+			if err := encodeXMLElement(encoder, key, item); err != nil {
+				return err
+			}
+		}
+	}
+	return encoder.EncodeToken(start.End())
+}
+
+// encodeXMLText writes an element with the scalar as its text.
+func encodeXMLText(encoder *xml.Encoder, start xml.StartElement, value interface{}) error {
+	text, err := csvField(value)
+	if err != nil {
+		return err
+	}
+	if err := encoder.EncodeToken(start); err != nil {
+		return err
+	}
+	if text != "" {
+		if err := encoder.EncodeToken(xml.CharData(text)); err != nil {
+			return err
+		}
+	}
+	return encoder.EncodeToken(start.End())
+}
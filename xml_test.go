@@ -0,0 +1,124 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUnmarshalXML(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected interface{}
+	}{
+		{"empty", "", nil},
+		{"text", "<name>2fy</name>", map[string]interface{}{"name": "2fy"}},
+		{"empty element", "<name/>", map[string]interface{}{"name": nil}},
+		{"children", "<app><name>2fy</name><port>80</port></app>", map[string]interface{}{
+			"app": map[string]interface{}{"name": "2fy", "port": "80"},
+		}},
+		{"attributes and text", `<port protocol="tcp">80</port>`, map[string]interface{}{
+			"port": map[string]interface{}{"@protocol": "tcp", "#text": "80"},
+		}},
+		{"repeated elements", "<ports><port>80</port><port>443</port></ports>", map[string]interface{}{
+			"ports": map[string]interface{}{"port": []interface{}{"80", "443"}},
+		}},
+		{"namespaces", `<a xmlns:x="urn:x"><x:b>1</x:b></a>`, map[string]interface{}{
+			"a": map[string]interface{}{"@xmlns:x": "urn:x", "b": "1"},
+		}},
+		{"whitespace", "<?xml version=\"1.0\"?>\n<a>\n  <b> 1 </b>\n</a>\n", map[string]interface{}{
+			"a": map[string]interface{}{"b": "1"},
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			object, err := unmarshalXML([]byte(test.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(object, test.expected) {
+				t.Errorf("expected %#v, got %#v", test.expected, object)
+			}
+		})
+	}
+}
+
+func TestUnmarshalXMLError(t *testing.T) {
+	if _, err := unmarshalXML([]byte("<a><b></a>")); err == nil {
+		t.Error("expected an error for the mismatched elements")
+	}
+}
+
+func TestMarshalXML(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"text", `"2fy"`, "<config>2fy</config>"},
+		{"null", `null`, ""},
+		{"children", `{"name":"2fy","port":80}`, "<config>\n  <name>2fy</name>\n  <port>80</port>\n</config>"},
+		{"attributes and text", `{"port":{"@protocol":"tcp","#text":80}}`, "<config>\n  <port protocol=\"tcp\">80</port>\n</config>"},
+		{"arrays", `{"port":[80,443]}`, "<config>\n  <port>80</port>\n  <port>443</port>\n</config>"},
+		{"escaping", `{"query":"a < b & c","@note":"\"quoted\""}`, "<config note=\"&#34;quoted&#34;\">\n  <query>a &lt; b &amp; c</query>\n</config>"},
+		{"empty element", `{"empty":null,"blank":""}`, "<config>\n  <blank></blank>\n  <empty></empty>\n</config>"},
+	}
+	defer func(root string) { xmlRoot = root }(xmlRoot)
+	xmlRoot = "config"
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output, err := convertText(jsonFormat, xmlFormat, test.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if output != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, output)
+			}
+		})
+	}
+}
+
+func TestMarshalXMLErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		root  string
+		input string
+	}{
+		{"no root", "", `{"a":1}`},
+		{"top-level array", "config", `[1,2]`},
+		{"nested arrays", "config", `{"a":[[1]]}`},
+		{"invalid element name", "config", `{"a b":1}`},
+		{"invalid attribute name", "config", `{"@a b":1}`},
+		{"object attribute", "config", `{"@a":{"b":1}}`},
+	}
+	defer func(root string) { xmlRoot = root }(xmlRoot)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			xmlRoot = test.root
+			if _, err := convertText(jsonFormat, xmlFormat, test.input); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestXMLRoundTrip(t *testing.T) {
+	defer func(root string) { xmlRoot = root }(xmlRoot)
+	xmlRoot = "config"
+	input := `<config version="1"><name>2fy</name><port>80</port><port>443</port></config>`
+	object, err := unmarshalXML([]byte(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	output, err := marshalXML(object.(map[string]interface{})["config"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := unmarshalXML(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, object) {
+		t.Errorf("expected %#v, got %#v", object, decoded)
+	}
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ghodss/yaml"
+	"github.com/urfave/cli"
+	yamlv2 "gopkg.in/yaml.v2"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+var documentSeparator = []byte("---")
+
+// yamlDocumentMarker starts a document, and separates the documents of a stream.
+const yamlDocumentMarker = "---\n"
+
+var docMarkers bool
+
+var docMarkersFlag = cli.BoolFlag{
+	Name:        "doc-markers",
+	Usage:       "start every YAML document with the '---' marker, the first one included",
+	Destination: &docMarkers,
+}
+
+// yamlLineWidth is the width the YAML encoder folds the long strings at.
+const yamlLineWidth = 80
+
+var lineWrap = yamlLineWidth
+
+var wrapFlag = cli.IntFlag{
+	Name:        "wrap",
+	Value:       yamlLineWidth,
+	Usage:       "the line width to fold the long YAML strings at, or 0 to never fold them, e.g. certificates or tokens",
+	Destination: &lineWrap,
+}
+
+var yamlLinePattern = regexp.MustCompile(`line (\d+)`)
+
+var strictYAML bool
+
+var strictFlag = cli.BoolFlag{
+	Name:        "strict, fail-on-parse-warnings",
+	Usage:       "fail on the duplicate keys of the YAML mappings instead of keeping the last value",
+	Destination: &strictYAML,
+}
+
+// yamlReader splits a multi-document YAML stream into its documents.
+type yamlReader struct {
+	reader      *bufio.Reader
+	pending     []byte
+	pendingLine int
+	// line is the number of lines read so far
+	line int
+	// start is the line of the stream where the last document read starts
+	start int
+}
+
+func newYAMLReader(r io.Reader) *yamlReader {
+	return &yamlReader{reader: bufio.NewReader(r)}
+}
+
+// Read returns the next non-empty document of the stream,
+// or io.EOF when there are no more documents.
+func (r *yamlReader) Read() ([]byte, error) {
+	var document bytes.Buffer
+	start := r.line + 1
+	if r.pending != nil {
+		start = r.pendingLine
+	}
+	document.Write(r.pending)
+	r.pending = nil
+	for {
+		line, err := r.reader.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if len(line) > 0 {
+			r.line++
+		}
+		if rest, ok := splitDocumentSeparator(line); ok {
+			if len(bytes.TrimSpace(document.Bytes())) > 0 {
+				r.pending, r.pendingLine = rest, r.line
+				r.start = start
+				return document.Bytes(), nil
+			}
+			document.Reset()
+			document.Write(rest)
+			start = r.line + 1
+			if rest != nil {
+				start = r.line
+			}
+		} else {
+			document.Write(line)
+		}
+		if err == io.EOF {
+			if len(bytes.TrimSpace(document.Bytes())) > 0 {
+				r.start = start
+				return document.Bytes(), nil
+			}
+			return nil, io.EOF
+		}
+	}
+}
+
+// splitDocumentSeparator reports whether the line starts a new document
+// and returns the content that follows the '---' marker on the same line.
+func splitDocumentSeparator(line []byte) ([]byte, bool) {
+	if !bytes.HasPrefix(line, documentSeparator) {
+		return nil, false
+	}
+	rest := line[len(documentSeparator):]
+	if len(rest) > 0 && rest[0] != ' ' && rest[0] != '\t' && rest[0] != '\r' && rest[0] != '\n' {
+		return nil, false
+	}
+	rest = bytes.TrimLeft(rest, " \t")
+	if len(bytes.TrimSpace(rest)) == 0 {
+		return nil, true
+	}
+	return rest, true
+}
+
+// yamlError makes the line numbers reported by the YAML parser, which are
+// relative to the document, relative to the stream the document starts at.
+func yamlError(err error, start int) error {
+	if start <= 1 {
+		return err
+	}
+	message := yamlLinePattern.ReplaceAllStringFunc(err.Error(), func(match string) string {
+		line, _ := strconv.Atoi(strings.TrimPrefix(match, "line "))
+		return fmt.Sprintf("line %d", line+start-1)
+	})
+	return errors.New(message)
+}
+
+// checkStrictYAML fails with the duplicated key when --strict is set,
+// since the default decoding silently keeps the last value.
+func checkStrictYAML(document []byte) error {
+	if !strictYAML {
+		return nil
+	}
+	var object interface{}
+	return yamlv2.UnmarshalStrict(document, &object)
+}
+
+// setupLineWrap applies --wrap. The yaml.v2 encoder folds the long strings at
+// its fixed width, or never at all after FutureLineWrap, the other widths
+// folding the unfolded output themselves with marshalFoldedYAML.
+func setupLineWrap() error {
+	if lineWrap < 0 {
+		return usageError(fmt.Sprintf("invalid --wrap %d, expected a line width or 0 to never fold the lines", lineWrap))
+	}
+	if lineWrap != yamlLineWidth {
+		yamlv2.FutureLineWrap()
+	}
+	return nil
+}
+
+// marshalFoldedYAML marshals the object with the strings that have spaces
+// replaced by placeholders, and then writes the strings in place of the
+// placeholders, folded at the --wrap width like the yaml.v2 encoder folds
+// at its own: at the first single space past the width, the continuation
+// lines being indented below their key or sequence item. The strings that
+// need double quotes are left unfolded.
+func marshalFoldedYAML(object interface{}) ([]byte, error) {
+	encoded, err := json.Marshal(object)
+	if err != nil {
+		return nil, err
+	}
+	prefix := "yamlfold"
+	for bytes.Contains(encoded, []byte(prefix)) {
+		prefix += "x"
+	}
+	var folded []string
+	output, err := yaml.Marshal(foldPlaceholders(object, prefix, &folded))
+	if err != nil {
+		return nil, err
+	}
+	lines := strings.SplitAfter(string(output), "\n")
+	for i, line := range lines {
+		start := strings.Index(line, prefix)
+		if start < 0 {
+			continue
+		}
+		end := strings.TrimRight(line[start+len(prefix):], "\n")
+		index, err := strconv.Atoi(end)
+		if err != nil || index >= len(folded) {
+			continue
+		}
+		value := foldYAMLString(folded[index], line[:start])
+		lines[i] = line[:start] + value + line[start+len(prefix)+len(end):]
+	}
+	return []byte(strings.Join(lines, "")), nil
+}
+
+// foldPlaceholders copies the value, replacing the strings that could be
+// folded with the prefix followed by their index in folded.
+func foldPlaceholders(value interface{}, prefix string, folded *[]string) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		object := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			object[key] = foldPlaceholders(item, prefix, folded)
+		}
+		return object
+	case []interface{}:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = foldPlaceholders(item, prefix, folded)
+		}
+		return items
+	case string:
+		if !strings.Contains(v, " ") || strings.Contains(v, "\n") {
+			return v
+		}
+		*folded = append(*folded, v)
+		return prefix + strconv.Itoa(len(*folded)-1)
+	}
+	return value
+}
+
+// foldYAMLString writes the string the way the yaml.v2 encoder does after
+// the line prefix, folding it when it is plain or single-quoted.
+func foldYAMLString(value, linePrefix string) string {
+	encoded, err := yamlv2.Marshal(value)
+	if err != nil {
+		return value
+	}
+	rendered := strings.TrimSuffix(string(encoded), "\n")
+	quoted := "'" + strings.Replace(value, "'", "''", -1) + "'"
+	if rendered != value && rendered != quoted {
+		return rendered
+	}
+	// the continuation lines are indented below the key, or at the item
+	content := strings.TrimLeft(linePrefix, " ")
+	for strings.HasPrefix(content, "- ") {
+		content = content[2:]
+	}
+	indent := len(linePrefix) - len(content)
+	if content != "" {
+		indent += 2
+	}
+
+	var output strings.Builder
+	column := utf8.RuneCountInString(linePrefix)
+	if rendered == quoted {
+		output.WriteByte('\'')
+		column++
+	}
+	runes := []rune(value)
+	for i, r := range runes {
+		switch {
+		case r == ' ' && column > lineWrap && i > 0 && i < len(runes)-1 && runes[i-1] != ' ' && runes[i+1] != ' ':
+			output.WriteString("\n" + strings.Repeat(" ", indent))
+			column = indent
+		case r == '\'' && rendered == quoted:
+			output.WriteString("''")
+			column += 2
+		default:
+			output.WriteRune(r)
+			column++
+		}
+	}
+	if rendered == quoted {
+		output.WriteByte('\'')
+	}
+	return output.String()
+}
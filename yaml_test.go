@@ -0,0 +1,203 @@
+package main
+
+import (
+	"github.com/urfave/cli"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestYAMLToYAML(t *testing.T) {
+	tests := []struct {
+		name     string
+		jsonpath string
+		input    string
+		expected string
+	}{
+		{"sorted keys", "", "name: 2fy\nkind: tool\n", "kind: tool\nname: 2fy\n"},
+		{"indentation", "", "a:\n    b:\n        - 1\n        -   2\n", "a:\n  b:\n  - 1\n  - 2\n"},
+		{"comments", "", "# header\na: 1 # trailing\n", "a: 1\n"},
+		{"quotes", "", "a: \"text\"\nb: '1'\nc: 'yes'\n", "a: text\nb: \"1\"\nc: \"yes\"\n"},
+		{"flow style", "", "a: {b: 1, c: [x, z]}\n", "a:\n  b: 1\n  c:\n  - x\n  - z\n"},
+		{"aliases", "", "a: &x {b: 1}\nc: *x\n", "a:\n  b: 1\nc:\n  b: 1\n"},
+		{"selected", "{.spec}", "kind: Deployment\nspec:\n  replicas: 3\n", "replicas: 3\n"},
+	}
+	defer func(templates []string) { jsonpathTemplates = templates }(jsonpathTemplates)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			jsonpathTemplates = nil
+			if test.jsonpath != "" {
+				jsonpathTemplates = []string{test.jsonpath}
+			}
+			output, err := convertText(yamlFormat, yamlFormat, test.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if output != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, output)
+			}
+		})
+	}
+}
+
+func TestUnmarshalYAMLErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		strict bool
+		input  string
+		line   string
+	}{
+		{"first document", false, "a: 1\n  b: 2\n", "line 2"},
+		{"later document", false, "a: 1\n---\nb: 2\nc: [\n", "line 4"},
+		{"after empty documents", false, "---\n---\na: 1\n  b: 2\n", "line 4"},
+		{"duplicate keys", true, "a: 1\na: 2\n", "line 2"},
+		{"duplicate keys of a later document", true, "a: 1\n---\nb: 1\nb: 2\n", "line 4"},
+	}
+	defer func(strict bool) { strictYAML = strict }(strictYAML)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			strictYAML = test.strict
+			_, err := unmarshalYAML([]byte(test.input))
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if !strings.Contains(err.Error(), test.line+":") {
+				t.Errorf("expected the error at %s, got %v", test.line, err)
+			}
+		})
+	}
+}
+
+func TestUnmarshalYAMLDuplicateKeys(t *testing.T) {
+	defer func(strict bool) { strictYAML = strict }(strictYAML)
+	strictYAML = false
+	object, err := unmarshalYAML([]byte("a: 1\na: 2\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value := object.(map[string]interface{})["a"]; value != float64(2) {
+		t.Errorf("expected the last value 2, got %v", value)
+	}
+}
+
+func TestUnmarshalInputErrorName(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "invalid.yaml")
+	if err := ioutil.WriteFile(path, []byte("a: [\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := unmarshalInput(path, unmarshalYAML)
+	if err == nil || !strings.HasPrefix(err.Error(), "cannot parse "+path+": ") {
+		t.Errorf("expected the error to name %s, got %v", path, err)
+	}
+}
+
+func TestLineWrap(t *testing.T) {
+	words := strings.TrimSpace(strings.Repeat("word ", 30))
+	tests := []struct {
+		name     string
+		width    int
+		input    string
+		expected string
+	}{
+		{"never", 0, `{"a":{"b":"` + words + `"}}`, "a:\n  b: " + words + "\n"},
+		{"default width", 80, `{"a":{"b":"` + words + `"}}`,
+			"a:\n  b: word word word word word word word word word word word word word word word word\n    word word word word word word word word word word word word word word\n"},
+		{"narrow", 40, `{"a":{"b":"` + words + `"}}`,
+			"a:\n  b: word word word word word word word word\n    word word word word word word word word\n    word word word word word word word word\n    word word word word word word\n"},
+		{"sequence items", 20, `{"list":["aaaa bbbb cccc dddd eeee ffff"]}`, "list:\n- aaaa bbbb cccc dddd\n  eeee ffff\n"},
+		{"single quoted", 20, `{"quoted":"yes: aaaa bbbb cccc dddd eeee"}`, "quoted: 'yes: aaaa bbbb\n  cccc dddd eeee'\n"},
+		{"short strings", 20, `{"short":"a b","none":null,"n":1}`, "\"n\": 1\nnone: null\nshort: a b\n"},
+		{"no spaces", 5, `{"token":"abcdefghijklmnopqrstuvwxyz"}`, "token: abcdefghijklmnopqrstuvwxyz\n"},
+		{"double spaces", 10, `{"a":"aaaa bbbb  cccc dddd"}`, "a: aaaa bbbb  cccc\n  dddd\n"},
+		{"placeholder prefix in the input", 20, `{"yamlfold0":"aaaa bbbb cccc dddd eeee"}`, "yamlfold0: aaaa bbbb cccc\n  dddd eeee\n"},
+	}
+	defer func(width int) { lineWrap = width }(lineWrap)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			lineWrap = test.width
+			if err := setupLineWrap(); err != nil {
+				t.Fatal(err)
+			}
+			object, err := unmarshalJSON([]byte(test.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			// the yaml.v2 encoder no longer folds at its width once another one was set up
+			marshal := marshalFoldedYAML
+			if test.width == 0 {
+				marshal = marshalYAML
+			}
+			output, err := marshal(object)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(output) != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, output)
+			}
+			decoded, err := unmarshalYAML(output)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(decoded, object) {
+				t.Errorf("expected %#v to read back, got %#v", object, decoded)
+			}
+		})
+	}
+}
+
+func TestLineWrapInvalid(t *testing.T) {
+	defer func(width int) { lineWrap = width }(lineWrap)
+	lineWrap = -1
+	if err := setupLineWrap(); err == nil {
+		t.Error("expected an error for --wrap -1")
+	}
+}
+
+func TestMaxLineLength(t *testing.T) {
+	tests := []struct {
+		name     string
+		max      int
+		wrap     bool
+		ndjson   bool
+		input    string
+		expected string
+		err      string
+	}{
+		{"within the limit", 10, false, false, `{"a":1}`, `{"a":1}`, ""},
+		{"no limit", 0, false, false, `{"a":"a long string value"}`, `{"a":"a long string value"}`, ""},
+		{"over the limit", 10, false, false, `{"a":"a long string"}`, "",
+			"line 1 of the output is 21 bytes long, over the --max-line-length of 10"},
+		{"wrapped", 10, true, false, `{"a":1,"b":2}`, "{\n  \"a\": 1,\n  \"b\": 2\n}", ""},
+		{"still over the limit once wrapped", 12, true, false, `{"a":1,"b":"a long string"}`, "",
+			"line 3 of the output is 22 bytes long, over the --max-line-length of 12"},
+		{"ndjson line over the limit", 8, false, true, `[{"a":1},{"b":"long"}]`, "",
+			"line 2 of the output is 12 bytes long, over the --max-line-length of 8"},
+	}
+	defer func(max int, wrap, lines bool) {
+		maxLineLength, wrapLongLines, ndjson = max, wrap, lines
+	}(maxLineLength, wrapLongLines, ndjson)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			maxLineLength, wrapLongLines, ndjson = test.max, test.wrap, test.ndjson
+			output, err := convertText(jsonFormat, jsonFormat, test.input)
+			if test.err == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if output != test.expected {
+					t.Errorf("expected %q, got %q", test.expected, output)
+				}
+				return
+			}
+			if err == nil || err.Error() != test.err {
+				t.Fatalf("expected the error %q, got %v", test.err, err)
+			}
+			// main exits with exitFailure for the errors without an exit code
+			if exit, ok := err.(cli.ExitCoder); ok && exit.ExitCode() != exitFailure {
+				t.Errorf("expected the exit code %d, got %d", exitFailure, exit.ExitCode())
+			}
+		})
+	}
+}